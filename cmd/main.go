@@ -48,15 +48,25 @@ func main() {
 	textHandler := slog.NewTextHandler(os.Stdout, nil)
 	logger := slog.New(textHandler)
 
+	servicesRepo := repositories.NewServicesPostgresRepository(postgres)
+	servicesUcases := usecases.NewServiceUseCases(servicesRepo)
+	sv := handlers.NewServicesRoutes(servicesUcases, logger)
+
 	repo := repositories.NewSubscriptionsPostgresRepository(postgres)
-	ucases := usecases.NewSubscriptionUseCases(repo)
+	ucases := usecases.NewSubscriptionUseCases(repo, servicesRepo)
 	sr := handlers.NewSubscriptionsRoutes(ucases, logger)
 
+	ratesRepo := repositories.NewExchangeRatesPostgresRepository(postgres)
+	ratesUcases := usecases.NewExchangeRateUseCases(ratesRepo)
+	er := handlers.NewExchangeRatesRoutes(ratesUcases, logger)
+
 	r := chi.NewRouter()
 	r.Use(middlewares.LoggingMiddleware(logger))
 
 	r.Get("/swagger/*", httpSwagger.WrapHandler)
 	sr.RegisterRoutes(r)
+	er.RegisterRoutes(r)
+	sv.RegisterRoutes(r)
 
 	log.Println("Server started!")
 
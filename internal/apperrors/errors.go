@@ -1,5 +1,138 @@
 package apperrors
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
 
-var SubscriptionNotFound = errors.New("Subscription not found")
+// Kind classifies application errors; the transport layer maps it to a status code.
+type Kind int
+
+const (
+	KindInternal Kind = iota
+	KindInvalid
+	KindNotFound
+	KindUnsupportedMediaType
+	KindPreconditionFailed
+	KindConflict
+)
+
+// FieldError describes a problem with a single request field or parameter.
+type FieldError struct {
+	Field   string `json:"field" example:"price"`
+	Code    string `json:"code" example:"negative"`
+	Message string `json:"message" example:"must not be negative"`
+}
+
+// Error is an application error with a machine-readable code.
+type Error struct {
+	Kind    Kind
+	Code    string
+	Message string
+	Fields  []FieldError
+}
+
+func (e *Error) Error() string {
+	return e.Message
+}
+
+// Is matches application errors by code, so errors carrying field details
+// still match the sentinel they were built from.
+func (e *Error) Is(target error) bool {
+	t, ok := target.(*Error)
+	return ok && t.Code == e.Code
+}
+
+// As returns the application error in err's chain, if any.
+func As(err error) (*Error, bool) {
+	var e *Error
+	ok := errors.As(err, &e)
+	return e, ok
+}
+
+var SubscriptionNotFound = &Error{Kind: KindNotFound, Code: "subscription_not_found", Message: "Subscription not found"}
+
+var VersionMismatch = &Error{
+	Kind:    KindPreconditionFailed,
+	Code:    "version_mismatch",
+	Message: "Subscription was modified by another request, reload it and retry",
+}
+
+var ExchangeRateNotFound = &Error{Kind: KindNotFound, Code: "exchange_rate_not_found", Message: "Exchange rate not found"}
+
+var ServiceNotFound = &Error{Kind: KindNotFound, Code: "service_not_found", Message: "Service not found"}
+
+var ServiceNameTaken = &Error{Kind: KindConflict, Code: "service_name_taken", Message: "Name or alias is already used by another service"}
+
+var ServiceInUse = &Error{Kind: KindConflict, Code: "service_in_use", Message: "Service has subscriptions and cannot be deleted"}
+
+var DiscountNotFound = &Error{Kind: KindNotFound, Code: "discount_not_found", Message: "Discount not found"}
+
+var DiscountOverlap = &Error{Kind: KindConflict, Code: "discount_overlap", Message: "Discount overlaps another discount of the subscription"}
+
+var DatesLocked = &Error{Kind: KindConflict, Code: "dates_locked", Message: "Start and end dates of a paused or cancelled subscription cannot be changed"}
+
+var InvalidBody = &Error{Kind: KindInvalid, Code: "invalid_body", Message: "Request body is malformed"}
+
+var InvalidDate = &Error{Kind: KindInvalid, Code: "invalid_date", Message: "Date must be in MM-YYYY format"}
+
+var UnsupportedMediaType = &Error{Kind: KindUnsupportedMediaType, Code: "unsupported_media_type", Message: "Unsupported content type"}
+
+var ValidationFailed = &Error{Kind: KindInvalid, Code: "validation_failed", Message: "Request validation failed"}
+
+var InvalidParameter = &Error{Kind: KindInvalid, Code: "invalid_parameter", Message: "Invalid request parameter"}
+
+// Invalid builds a validation error reporting every violated field at once.
+func Invalid(fields ...FieldError) *Error {
+	return &Error{Kind: KindInvalid, Code: ValidationFailed.Code, Message: ValidationFailed.Message, Fields: fields}
+}
+
+// InvalidParam builds an error for a malformed path or query parameter.
+func InvalidParam(name, message string) *Error {
+	return &Error{
+		Kind:    KindInvalid,
+		Code:    InvalidParameter.Code,
+		Message: InvalidParameter.Message,
+		Fields:  []FieldError{{Field: name, Code: "invalid", Message: message}},
+	}
+}
+
+// MalformedBody builds an error for a request body that cannot be decoded.
+func MalformedBody(cause error) *Error {
+	return &Error{Kind: KindInvalid, Code: InvalidBody.Code, Message: fmt.Sprintf("%s: %v", InvalidBody.Message, cause)}
+}
+
+// NoExchangeRate builds an error for amounts that cannot be converted to currency
+// because an exchange rate is missing.
+func NoExchangeRate(currency string, from []string) *Error {
+	return &Error{
+		Kind:    KindInvalid,
+		Code:    "exchange_rate_missing",
+		Message: fmt.Sprintf("No exchange rate to convert %s to %s", strings.Join(from, ", "), currency),
+	}
+}
+
+// ConstraintViolated builds an error for data breaking a database constraint.
+func ConstraintViolated(constraint string) *Error {
+	return &Error{
+		Kind:    KindConflict,
+		Code:    "constraint_violated",
+		Message: fmt.Sprintf("Violates the %s constraint", constraint),
+	}
+}
+
+// ValueRejected builds an error for a value the database cannot store.
+func ValueRejected(reason string) *Error {
+	return &Error{Kind: KindInvalid, Code: "value_rejected", Message: "Value rejected: " + reason}
+}
+
+// InvalidTransition builds an error for a status transition the subscription
+// status does not allow.
+func InvalidTransition(action, status string) *Error {
+	return &Error{
+		Kind:    KindConflict,
+		Code:    "invalid_status_transition",
+		Message: fmt.Sprintf("Cannot %s a %s subscription", action, status),
+	}
+}
@@ -0,0 +1,116 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+)
+
+// writeBatch responds with the batch result, using status when it was committed
+// and 422 when items failed and nothing was changed.
+func (sr *SubscriptionsRoutes) writeBatch(w http.ResponseWriter, res types.BatchResponse, status int) {
+	if !res.Committed {
+		status = http.StatusUnprocessableEntity
+	}
+
+	w.WriteHeader(status)
+	err := responses.SetJsonBody(w, res)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", res), slog.Any("err", err))
+	}
+}
+
+// CreateSubscriptions godoc
+// @Summary Create subscriptions in batch
+// @Description Create all subscriptions in one transaction. If any item is invalid nothing is created
+// @Description and the failed items are reported with their index in the request.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param request body []types.SubscriptionRequest true "Subscriptions data"
+// @Success 201 {object} types.BatchResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 422 {object} types.BatchResponse
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/batch [post]
+func (sr *SubscriptionsRoutes) CreateSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var subsReq []types.SubscriptionRequest
+
+	if err := decodeJSON(r, &subsReq); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	res, err := sr.uc.SaveSubscriptionsBatch(subsReq, actor(r))
+	if err != nil {
+		writeError(w, sr.logger, "Repo failed on batch create", err, slog.Int("count", len(subsReq)))
+		return
+	}
+
+	sr.writeBatch(w, res, http.StatusCreated)
+}
+
+// UpdateSubscriptions godoc
+// @Summary Update subscriptions in batch
+// @Description Replace all subscriptions in one transaction. An item with version is only updated when
+// @Description the subscription has that version, and the dates of a paused or cancelled subscription cannot change.
+// @Description If any item fails nothing is updated.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param request body []types.SubscriptionUpdateItem true "Subscriptions data with ids"
+// @Success 200 {object} types.BatchResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 422 {object} types.BatchResponse
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/batch [put]
+func (sr *SubscriptionsRoutes) UpdateSubscriptions(w http.ResponseWriter, r *http.Request) {
+	var items []types.SubscriptionUpdateItem
+
+	if err := decodeJSON(r, &items); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	res, err := sr.uc.UpdateSubscriptionsBatch(items, actor(r))
+	if err != nil {
+		writeError(w, sr.logger, "Repo failed on batch update", err, slog.Int("count", len(items)))
+		return
+	}
+
+	sr.writeBatch(w, res, http.StatusOK)
+}
+
+// DeleteSubscriptions godoc
+// @Summary Delete subscriptions in batch
+// @Description Soft-delete all subscriptions in one transaction. If any of them is not found nothing is deleted.
+// @Tags subscriptions
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param ids query string true "Comma separated subscription ids" example(1,2,3)
+// @Success 200 {object} types.BatchResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 422 {object} types.BatchResponse
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/batch [delete]
+func (sr *SubscriptionsRoutes) DeleteSubscriptions(w http.ResponseWriter, r *http.Request) {
+	ids, err := parseIDsParam(r.URL.Query(), "ids")
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	res, err := sr.uc.DeleteSubscriptionsBatch(ids, actor(r))
+	if err != nil {
+		writeError(w, sr.logger, "Repo failed on batch delete", err, slog.Any("ids", ids))
+		return
+	}
+
+	sr.writeBatch(w, res, http.StatusOK)
+}
@@ -0,0 +1,159 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"strconv"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func parseDiscountID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "discount_id"))
+	if err != nil || id <= 0 {
+		return 0, apperrors.InvalidParam("discount_id", "must be a positive integer")
+	}
+	return id, nil
+}
+
+// AddDiscount godoc
+// @Summary Add subscription trial or discount
+// @Description Schedule a free trial, a percent or a fixed discount for a number of months, starting at the
+// @Description subscription start unless start_month is given. Totals charge nothing during a trial and reduce the
+// @Description charges of discounted months. Discounts of a subscription must not overlap.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Param request body types.DiscountRequest true "Trial or discount"
+// @Success 201 {object} types.Discount
+// @Header 201 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/discounts [post]
+func (sr *SubscriptionsRoutes) AddDiscount(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	var req types.DiscountRequest
+
+	if err := decodeJSON(r, &req); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, discount, err := sr.uc.AddDiscount(id, req, expectedVersion, actor(r))
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Add discount", err, slog.Int("id", id), slog.Any("obj", req))
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+	w.WriteHeader(http.StatusCreated)
+	err = responses.SetJsonBody(w, discount)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", discount), slog.Any("err", err))
+	}
+}
+
+// GetDiscounts godoc
+// @Summary Get subscription trials and discounts
+// @Description Get subscription trials and discounts ordered by the month they start
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} types.Discount
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/discounts [get]
+func (sr *SubscriptionsRoutes) GetDiscounts(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	discounts, err := sr.uc.GetDiscounts(id)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get discounts", err, slog.Int("id", id))
+		return
+	}
+
+	err = responses.SetJsonBody(w, discounts)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", discounts), slog.Any("err", err))
+	}
+}
+
+// DeleteDiscount godoc
+// @Summary Delete subscription trial or discount
+// @Description Remove a trial or a discount, its months are charged in full again
+// @Tags subscriptions
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param discount_id path int true "Discount ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/discounts/{discount_id} [delete]
+func (sr *SubscriptionsRoutes) DeleteDiscount(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	discountID, err := parseDiscountID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, err := sr.uc.DeleteDiscount(id, discountID, expectedVersion, actor(r))
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Delete discount", err, slog.Int("id", id), slog.Int("discount_id", discountID))
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+
+	err = responses.SetJsonBody(w, sub)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,137 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ExchangeRatesRoutes struct {
+	uc     usecases.ExchangeRateUseCases
+	logger *slog.Logger
+}
+
+func NewExchangeRatesRoutes(uc usecases.ExchangeRateUseCases, logger *slog.Logger) ExchangeRatesRoutes {
+	return ExchangeRatesRoutes{uc, logger}
+}
+
+func (er *ExchangeRatesRoutes) RegisterRoutes(r chi.Router) {
+	r.Route("/exchange-rates", func(r chi.Router) {
+		r.Get("/", er.GetExchangeRates)
+		r.Get("/{currency}", er.GetExchangeRate)
+		r.Put("/{currency}", er.SetExchangeRate)
+		r.Delete("/{currency}", er.DeleteExchangeRate)
+	})
+}
+
+// GetExchangeRates godoc
+// @Summary Get exchange rates
+// @Description Get rates of currencies to the base currency RUB used to convert subscription prices in stats
+// @Tags exchange-rates
+// @Produce json
+// @Success 200 {array} types.ExchangeRate
+// @Failure 500 {object} responses.Problem
+// @Router /exchange-rates [get]
+func (er *ExchangeRatesRoutes) GetExchangeRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := er.uc.GetExchangeRates()
+
+	if err != nil {
+		writeError(w, er.logger, "Repo Get exchange rates", err)
+		return
+	}
+
+	err = responses.SetJsonBody(w, rates)
+
+	if err != nil {
+		er.logger.Error("Json set body", slog.Any("obj", rates), slog.Any("err", err))
+	}
+}
+
+// GetExchangeRate godoc
+// @Summary Get exchange rate
+// @Description Get rate of a currency to the base currency RUB
+// @Tags exchange-rates
+// @Produce json
+// @Param currency path string true "ISO 4217 currency code" example(USD)
+// @Success 200 {object} types.ExchangeRate
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /exchange-rates/{currency} [get]
+func (er *ExchangeRatesRoutes) GetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	currency := chi.URLParam(r, "currency")
+
+	rate, err := er.uc.GetExchangeRate(currency)
+
+	if err != nil {
+		writeError(w, er.logger, "Repo Get exchange rate", err, slog.String("currency", currency))
+		return
+	}
+
+	err = responses.SetJsonBody(w, rate)
+
+	if err != nil {
+		er.logger.Error("Json set body", slog.Any("obj", rate), slog.Any("err", err))
+	}
+}
+
+// SetExchangeRate godoc
+// @Summary Set exchange rate
+// @Description Create or replace rate of a currency: the price of one unit of it in RUB. The rate of RUB is always 1.
+// @Tags exchange-rates
+// @Accept json
+// @Produce json
+// @Param currency path string true "ISO 4217 currency code" example(USD)
+// @Param request body types.ExchangeRateRequest true "Rate"
+// @Success 200 {object} types.ExchangeRate
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /exchange-rates/{currency} [put]
+func (er *ExchangeRatesRoutes) SetExchangeRate(w http.ResponseWriter, r *http.Request) {
+	currency := chi.URLParam(r, "currency")
+
+	var rateReq types.ExchangeRateRequest
+
+	if err := decodeJSON(r, &rateReq); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	rate, err := er.uc.SetExchangeRate(currency, rateReq)
+
+	if err != nil {
+		writeError(w, er.logger, "Repo Set exchange rate", err, slog.String("currency", currency), slog.Any("obj", rateReq))
+		return
+	}
+
+	err = responses.SetJsonBody(w, rate)
+
+	if err != nil {
+		er.logger.Error("Json set body", slog.Any("obj", rate), slog.Any("err", err))
+	}
+}
+
+// DeleteExchangeRate godoc
+// @Summary Delete exchange rate
+// @Description Delete rate of a currency, stats in or from that currency fail until it is set again
+// @Tags exchange-rates
+// @Param currency path string true "ISO 4217 currency code" example(USD)
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /exchange-rates/{currency} [delete]
+func (er *ExchangeRatesRoutes) DeleteExchangeRate(w http.ResponseWriter, r *http.Request) {
+	currency := chi.URLParam(r, "currency")
+
+	if err := er.uc.DeleteExchangeRate(currency); err != nil {
+		writeError(w, er.logger, "Repo Delete exchange rate", err, slog.String("currency", currency))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,255 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+)
+
+var exportContentTypes = map[string]string{
+	types.ExportCSV:    "text/csv",
+	types.ExportNDJSON: "application/x-ndjson",
+}
+
+// exportWriter writes records as CSV rows or as JSON lines.
+type exportWriter struct {
+	csv  *csv.Writer
+	json *json.Encoder
+}
+
+// newExportWriter sets the export headers and returns a writer for format.
+// The CSV header row is written first.
+func newExportWriter(w http.ResponseWriter, format, name string, header []string) (*exportWriter, error) {
+	w.Header().Set("Content-Type", exportContentTypes[format])
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.%s"`, name, format))
+
+	if format == types.ExportNDJSON {
+		return &exportWriter{json: json.NewEncoder(w)}, nil
+	}
+
+	ew := &exportWriter{csv: csv.NewWriter(w)}
+	return ew, ew.csv.Write(header)
+}
+
+// write writes record as a CSV row or v as a JSON line.
+func (ew *exportWriter) write(record func() []string, v any) error {
+	if ew.json != nil {
+		return ew.json.Encode(v)
+	}
+	return ew.csv.Write(record())
+}
+
+func (ew *exportWriter) flush() error {
+	if ew.csv == nil {
+		return nil
+	}
+
+	ew.csv.Flush()
+	return ew.csv.Error()
+}
+
+func parseExportFormat(q url.Values) (string, error) {
+	format := q.Get("format")
+	if len(format) == 0 {
+		return types.ExportCSV, nil
+	}
+
+	if !types.IsValidExportFormat(format) {
+		return "", apperrors.InvalidParam("format", "must be one of csv, ndjson")
+	}
+
+	return format, nil
+}
+
+func formatMonth(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format("01-2006")
+}
+
+func formatDate(t *time.Time) string {
+	if t == nil {
+		return ""
+	}
+	return t.Format(time.DateOnly)
+}
+
+func subscriptionRecord(sub types.SubscriptionResponse) []string {
+	deletedAt := ""
+	if sub.DeletedAt != nil {
+		deletedAt = sub.DeletedAt.Format(time.RFC3339)
+	}
+
+	return []string{
+		strconv.Itoa(sub.ID),
+		strconv.Itoa(sub.ServiceID),
+		sub.ServiceName,
+		strconv.Itoa(sub.Price),
+		sub.Currency,
+		sub.UserID,
+		formatMonth(&sub.StartDate),
+		formatMonth(sub.EndDate),
+		sub.BillingPeriod,
+		formatDate(sub.BillingAnchor),
+		sub.Status,
+		strconv.Itoa(sub.Version),
+		deletedAt,
+	}
+}
+
+var subscriptionExportHeader = []string{"id", "service_id", "service_name", "price", "currency", "user_id", "start_date", "end_date", "billing_period", "billing_anchor", "status", "version", "deleted_at"}
+
+// ExportSubscriptions godoc
+// @Summary Export subscriptions
+// @Description Stream all subscriptions matching the list filters as CSV with a header row or as
+// @Description newline delimited JSON objects. Dates in CSV are in MM-YYYY format, billing_anchor in YYYY-MM-DD.
+// @Tags subscriptions
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "Export format, csv by default" Enums(csv, ndjson)
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Case-insensitive service name prefix"
+// @Param price_min query int false "Minimal price"
+// @Param price_max query int false "Maximal price"
+// @Param start_from query string false "Earliest start date (MM-YYYY)"
+// @Param start_to query string false "Latest start date (MM-YYYY)"
+// @Param active_at query string false "Month the subscriptions are active in (MM-YYYY)"
+// @Param status query string false "Subscription status" Enums(active, paused, cancelled)
+// @Param sort query string false "Comma separated sort fields, minus for descending order" example(price,-start_date)
+// @Param include_deleted query bool false "Include soft-deleted subscriptions"
+// @Success 200 {string} string "Exported subscriptions"
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/export [get]
+func (sr *SubscriptionsRoutes) ExportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format, err := parseExportFormat(q)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	filter, err := parseSubscriptionsFilter(q)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sort, err := types.ParseSort(q.Get("sort"))
+	if err != nil {
+		responses.SetError(w, apperrors.InvalidParam("sort", err.Error()))
+		return
+	}
+
+	// Until the first row is written a failure can still be reported as a problem.
+	var ew *exportWriter
+
+	err = sr.uc.ExportSubscriptions(filter, sort, func(sub types.SubscriptionResponse) error {
+		if ew == nil {
+			var err error
+			if ew, err = newExportWriter(w, format, "subscriptions", subscriptionExportHeader); err != nil {
+				return err
+			}
+		}
+
+		return ew.write(func() []string { return subscriptionRecord(sub) }, sub)
+	})
+
+	if err == nil && ew == nil {
+		ew, err = newExportWriter(w, format, "subscriptions", subscriptionExportHeader)
+	}
+
+	if err == nil {
+		err = ew.flush()
+	}
+
+	if err != nil {
+		sr.exportFailed(w, ew != nil, "Repo Export subs", err, slog.String("format", format), slog.Any("filter", filter))
+	}
+}
+
+// exportFailed reports err as a problem, or only logs it once the export has started.
+func (sr *SubscriptionsRoutes) exportFailed(w http.ResponseWriter, started bool, msg string, err error, attrs ...any) {
+	if !started {
+		writeError(w, sr.logger, msg, err, attrs...)
+		return
+	}
+
+	sr.logger.Error(msg, append(attrs, slog.Any("err", err))...)
+}
+
+// ExportCostBreakdown godoc
+// @Summary Export subscription cost breakdown
+// @Description Export the cost breakdown of /subscriptions/stats/breakdown as CSV with key, total and count
+// @Description columns or as newline delimited JSON objects.
+// @Tags subscriptions
+// @Produce text/csv,application/x-ndjson
+// @Param format query string false "Export format, csv by default" Enums(csv, ndjson)
+// @Param group_by query string true "Grouping key" Enums(service_name, user_id, month)
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Service name"
+// @Param start_date query string false "Start date (MM-YYYY)"
+// @Param end_date query string false "End date (MM-YYYY)"
+// @Param currency query string false "ISO 4217 currency the amounts are converted to, RUB by default" example(USD)
+// @Success 200 {string} string "Exported breakdown"
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/stats/breakdown/export [get]
+func (sr *SubscriptionsRoutes) ExportCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	format, err := parseExportFormat(q)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	groupBy := q.Get("group_by")
+	if !types.IsValidGroupBy(groupBy) {
+		responses.SetError(w, apperrors.InvalidParam("group_by", "must be one of service_name, user_id, month"))
+		return
+	}
+
+	filter, err := parseStatsFilter(q)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	breakdown, err := sr.uc.GetCostBreakdown(groupBy, filter)
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get breakdown", err, slog.String("group_by", groupBy), slog.Any("filter", filter))
+		return
+	}
+
+	ew, err := newExportWriter(w, format, "breakdown_"+groupBy, []string{"key", "total", "count"})
+
+	for _, item := range breakdown.Items {
+		if err != nil {
+			break
+		}
+
+		err = ew.write(func() []string {
+			return []string{item.Key, strconv.Itoa(item.Total), strconv.Itoa(item.Count)}
+		}, item)
+	}
+
+	if err == nil {
+		err = ew.flush()
+	}
+
+	if err != nil {
+		sr.logger.Error("Export breakdown", slog.String("format", format), slog.Any("err", err))
+	}
+}
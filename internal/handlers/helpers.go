@@ -0,0 +1,315 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+
+	"github.com/go-chi/chi/v5"
+	"github.com/google/uuid"
+)
+
+// writeError responds with a problem body for err. Unexpected errors are logged
+// with msg and attrs, application errors are expected and only returned to the client.
+func writeError(w http.ResponseWriter, logger *slog.Logger, msg string, err error, attrs ...any) {
+	if _, ok := apperrors.As(err); !ok {
+		logger.Error(msg, append(attrs, slog.Any("err", err))...)
+	}
+
+	responses.SetError(w, err)
+}
+
+func decodeJSON(r *http.Request, v any) error {
+	body, err := io.ReadAll(r.Body)
+	if err != nil {
+		return apperrors.MalformedBody(err)
+	}
+
+	err = json.Unmarshal(body, v)
+	if err == nil {
+		return nil
+	}
+
+	if appErr, ok := apperrors.As(err); ok {
+		return appErr
+	}
+
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok && len(typeErr.Field) != 0 {
+		return apperrors.Invalid(apperrors.FieldError{
+			Field:   typeErr.Field,
+			Code:    "invalid_type",
+			Message: fmt.Sprintf("must be %s", typeErr.Type),
+		})
+	}
+
+	return apperrors.MalformedBody(err)
+}
+
+func parseID(r *http.Request) (int, error) {
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		return 0, apperrors.InvalidParam("id", "must be a positive integer")
+	}
+	return id, nil
+}
+
+func parseUserIDPath(r *http.Request) (string, error) {
+	userID, err := uuid.Parse(chi.URLParam(r, "user_id"))
+	if err != nil {
+		return "", apperrors.InvalidParam("user_id", "must be a UUID")
+	}
+	return userID.String(), nil
+}
+
+// parseIDsParam parses a comma separated list of subscription ids.
+func parseIDsParam(q url.Values, name string) ([]int, error) {
+	value := q.Get(name)
+	if len(value) == 0 {
+		return nil, apperrors.InvalidParam(name, "must not be empty")
+	}
+
+	parts := strings.Split(value, ",")
+	ids := make([]int, len(parts))
+
+	for i, part := range parts {
+		id, err := strconv.Atoi(strings.TrimSpace(part))
+		if err != nil || id <= 0 {
+			return nil, apperrors.InvalidParam(name, "must be a comma separated list of positive integers")
+		}
+		ids[i] = id
+	}
+
+	return ids, nil
+}
+
+// actor identifies who makes a change for the subscription history.
+func actor(r *http.Request) string {
+	return strings.TrimSpace(r.Header.Get("X-Actor"))
+}
+
+func etag(version int) string {
+	return fmt.Sprintf(`"%d"`, version)
+}
+
+// parseIfMatch returns the subscription version required by the If-Match header.
+// A missing header or "*" matches any version. If-Match uses the strong
+// comparison, so a weak ETag never matches and fails the precondition. Only a
+// single ETag is supported, a list of them is rejected.
+func parseIfMatch(r *http.Request) (*int, error) {
+	value := strings.TrimSpace(r.Header.Get("If-Match"))
+	if len(value) == 0 || value == "*" {
+		return nil, nil
+	}
+
+	if strings.HasPrefix(value, "W/") {
+		return nil, apperrors.VersionMismatch
+	}
+
+	if strings.Contains(value, ",") {
+		return nil, apperrors.InvalidParam("If-Match", "must be a single ETag of the subscription")
+	}
+
+	version, err := strconv.Atoi(strings.Trim(value, `"`))
+	if err != nil || len(value) < 2 || value[0] != '"' || value[len(value)-1] != '"' {
+		return nil, apperrors.InvalidParam("If-Match", "must be an ETag of the subscription")
+	}
+
+	return &version, nil
+}
+
+func parsePositiveParam(q url.Values, name string) (int, error) {
+	n, err := strconv.Atoi(q.Get(name))
+	if err != nil || n <= 0 {
+		return 0, apperrors.InvalidParam(name, "must be a positive integer")
+	}
+	return n, nil
+}
+
+func parseIntParam(q url.Values, name string) (*int, error) {
+	value := q.Get(name)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return nil, apperrors.InvalidParam(name, "must be an integer")
+	}
+
+	return &n, nil
+}
+
+func parseMonthParam(q url.Values, name string) (*time.Time, error) {
+	value := q.Get(name)
+	if len(value) == 0 {
+		return nil, nil
+	}
+
+	t, err := time.Parse("01-2006", value)
+	if err != nil {
+		return nil, apperrors.InvalidParam(name, "must be a month in MM-YYYY format")
+	}
+
+	return &t, nil
+}
+
+func parseRequiredMonthParam(q url.Values, name string) (*time.Time, error) {
+	if len(q.Get(name)) == 0 {
+		return nil, apperrors.InvalidParam(name, "must be set")
+	}
+	return parseMonthParam(q, name)
+}
+
+func parseUserIDParam(q url.Values, name string) (string, error) {
+	value := q.Get(name)
+	if len(value) == 0 {
+		return "", nil
+	}
+
+	if _, err := uuid.Parse(value); err != nil {
+		return "", apperrors.InvalidParam(name, "must be a UUID")
+	}
+
+	return value, nil
+}
+
+func parseSubscriptionsFilter(q url.Values) (types.SubscriptionsFilter, error) {
+	filter := types.SubscriptionsFilter{
+		ServiceName:       q.Get("service_name"),
+		ServiceNamePrefix: q.Get("service_name_prefix"),
+	}
+
+	var err error
+
+	if q.Has("include_deleted") {
+		includeDeleted, err := strconv.ParseBool(q.Get("include_deleted"))
+		if err != nil {
+			return types.SubscriptionsFilter{}, apperrors.InvalidParam("include_deleted", "must be a boolean")
+		}
+		if includeDeleted {
+			filter.Deleted = types.IncludeDeleted
+		}
+	}
+
+	if filter.UserID, err = parseUserIDParam(q, "user_id"); err != nil {
+		return types.SubscriptionsFilter{}, err
+	}
+
+	if filter.PriceMin, err = parseIntParam(q, "price_min"); err != nil {
+		return types.SubscriptionsFilter{}, err
+	}
+
+	if filter.PriceMax, err = parseIntParam(q, "price_max"); err != nil {
+		return types.SubscriptionsFilter{}, err
+	}
+
+	if filter.StartFrom, err = parseMonthParam(q, "start_from"); err != nil {
+		return types.SubscriptionsFilter{}, err
+	}
+
+	if filter.StartTo, err = parseMonthParam(q, "start_to"); err != nil {
+		return types.SubscriptionsFilter{}, err
+	}
+
+	if filter.ActiveAt, err = parseMonthParam(q, "active_at"); err != nil {
+		return types.SubscriptionsFilter{}, err
+	}
+
+	if filter.Status = q.Get("status"); len(filter.Status) != 0 && !types.IsValidStatus(filter.Status) {
+		return types.SubscriptionsFilter{}, apperrors.InvalidParam("status", "must be one of active, paused, cancelled")
+	}
+
+	return filter, nil
+}
+
+// parseCurrencyParam returns the currency stats are converted to, the base currency by default.
+func parseCurrencyParam(q url.Values, name string) (string, error) {
+	currency := strings.ToUpper(q.Get(name))
+	if len(currency) == 0 {
+		return types.BaseCurrency, nil
+	}
+
+	if !types.IsValidCurrency(currency) {
+		return "", apperrors.InvalidParam(name, "must be an ISO 4217 currency code")
+	}
+
+	return currency, nil
+}
+
+func parseStatsFilter(q url.Values) (types.StatsFilter, error) {
+	filter := types.StatsFilter{
+		ServiceName: q.Get("service_name"),
+	}
+
+	var err error
+
+	if filter.UserID, err = parseUserIDParam(q, "user_id"); err != nil {
+		return types.StatsFilter{}, err
+	}
+
+	if filter.StartDate, err = parseMonthParam(q, "start_date"); err != nil {
+		return types.StatsFilter{}, err
+	}
+
+	if filter.EndDate, err = parseMonthParam(q, "end_date"); err != nil {
+		return types.StatsFilter{}, err
+	}
+
+	if filter.Currency, err = parseCurrencyParam(q, "currency"); err != nil {
+		return types.StatsFilter{}, err
+	}
+
+	return filter, nil
+}
+
+func withQueryParam(u *url.URL, key, value string) string {
+	q := u.Query()
+	q.Set(key, value)
+	return u.Path + "?" + q.Encode()
+}
+
+// linkHeader renders an RFC 8288 Link header value in a stable rel order.
+func linkHeader(links map[string]string) string {
+	parts := make([]string, 0, len(links))
+	for _, rel := range []string{"first", "prev", "next", "last"} {
+		if link, ok := links[rel]; ok {
+			parts = append(parts, fmt.Sprintf(`<%s>; rel="%s"`, link, rel))
+		}
+	}
+	return strings.Join(parts, ", ")
+}
+
+// setPageLinks fills next/prev links of the page and sets Link and X-Total-Count headers.
+func setPageLinks(w http.ResponseWriter, u *url.URL, res *types.SubscriptionsPageResponse) {
+	lastPage := max(1, (res.Total+res.Count-1)/res.Count)
+
+	links := map[string]string{
+		"first": withQueryParam(u, "page", "1"),
+		"last":  withQueryParam(u, "page", strconv.Itoa(lastPage)),
+	}
+
+	if res.Page < lastPage {
+		next := withQueryParam(u, "page", strconv.Itoa(res.Page+1))
+		res.Next = &next
+		links["next"] = next
+	}
+
+	if res.Page > 1 {
+		prev := withQueryParam(u, "page", strconv.Itoa(min(res.Page-1, lastPage)))
+		res.Prev = &prev
+		links["prev"] = prev
+	}
+
+	w.Header().Set("Link", linkHeader(links))
+	w.Header().Set("X-Total-Count", strconv.Itoa(res.Total))
+}
@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"errors"
+	"net/http/httptest"
+	"subscriptions-api/internal/apperrors"
+	"testing"
+)
+
+func TestParseIfMatch(t *testing.T) {
+	version := func(v int) *int { return &v }
+
+	tests := []struct {
+		name    string
+		header  string
+		want    *int
+		wantErr error
+	}{
+		{name: "missing", header: "", want: nil},
+		{name: "any", header: "*", want: nil},
+		{name: "strong", header: `"3"`, want: version(3)},
+		{name: "surrounding spaces", header: ` "3" `, want: version(3)},
+		{name: "weak never matches", header: `W/"3"`, wantErr: apperrors.VersionMismatch},
+		{name: "list", header: `"3", "4"`, wantErr: apperrors.InvalidParameter},
+		{name: "unquoted", header: `3`, wantErr: apperrors.InvalidParameter},
+		{name: "half quoted", header: `"3`, wantErr: apperrors.InvalidParameter},
+		{name: "not a version", header: `"abc"`, wantErr: apperrors.InvalidParameter},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := httptest.NewRequest("PUT", "/subscriptions/1", nil)
+			if tt.header != "" {
+				r.Header.Set("If-Match", tt.header)
+			}
+
+			got, err := parseIfMatch(r)
+
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("parseIfMatch(%q) error = %v, want %v", tt.header, err, tt.wantErr)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("parseIfMatch(%q) unexpected error: %v", tt.header, err)
+			}
+
+			if (got == nil) != (tt.want == nil) || got != nil && *got != *tt.want {
+				t.Errorf("parseIfMatch(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+		})
+	}
+}
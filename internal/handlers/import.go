@@ -0,0 +1,247 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/validation"
+
+	"github.com/google/uuid"
+)
+
+var (
+	tooManyImportRows = apperrors.Invalid(apperrors.FieldError{
+		Field:   "body",
+		Code:    "too_many",
+		Message: fmt.Sprintf("must have at most %d rows", validation.MaxImportRows),
+	})
+
+	importTooLarge = apperrors.Invalid(apperrors.FieldError{
+		Field:   "body",
+		Code:    "too_large",
+		Message: fmt.Sprintf("must be at most %d bytes", validation.MaxImportBytes),
+	})
+)
+
+// importReadError reports an error reading the import body.
+func importReadError(err error) error {
+	var maxErr *http.MaxBytesError
+	if errors.As(err, &maxErr) {
+		return importTooLarge
+	}
+	return apperrors.MalformedBody(err)
+}
+
+// readImportHeader maps the import columns to their positions in the file header.
+func readImportHeader(cr *csv.Reader) (map[string]int, error) {
+	header, err := cr.Read()
+	if errors.Is(err, io.EOF) {
+		return nil, apperrors.Invalid(apperrors.FieldError{Field: "header", Code: "required", Message: "must not be empty"})
+	}
+	if err != nil {
+		return nil, importReadError(err)
+	}
+
+	columns := make(map[string]int, len(header))
+	var v validation.Validator
+
+	for i, name := range header {
+		name = strings.ToLower(strings.TrimSpace(strings.TrimPrefix(name, "\ufeff")))
+		v.Check(slices.Contains(types.ImportColumns, name), "header", "unknown_column", fmt.Sprintf("unknown column %q", name))
+		columns[name] = i
+	}
+
+	for _, name := range types.ImportColumns[:types.RequiredImportColumns] {
+		_, ok := columns[name]
+		v.Check(ok, "header", "missing_column", fmt.Sprintf("missing column %q", name))
+	}
+
+	if err := v.Err(); err != nil {
+		return nil, err
+	}
+
+	return columns, nil
+}
+
+// parseImportRecord converts a CSV record into a subscription, collecting the
+// values that cannot be parsed instead of stopping at the first one.
+func parseImportRecord(record []string, columns map[string]int) (types.SubscriptionRequest, []apperrors.FieldError) {
+	value := func(name string) string {
+		if i, ok := columns[name]; ok && i < len(record) {
+			return strings.TrimSpace(record[i])
+		}
+		return ""
+	}
+
+	var sub types.SubscriptionRequest
+	var errs []apperrors.FieldError
+
+	sub.ServiceName = value("service_name")
+	sub.Currency = value("currency")
+
+	if priceValue := value("price"); len(priceValue) != 0 {
+		price, err := strconv.Atoi(priceValue)
+		if err != nil {
+			errs = append(errs, apperrors.FieldError{Field: "price", Code: "invalid_type", Message: "must be int"})
+		}
+		sub.Price = &price
+	}
+
+	userID, err := uuid.Parse(value("user_id"))
+	if err != nil {
+		errs = append(errs, apperrors.FieldError{Field: "user_id", Code: "invalid_type", Message: "must be a UUID"})
+	}
+	sub.UserID = userID
+
+	startDate, err := time.Parse("01-2006", value("start_date"))
+	if err != nil {
+		errs = append(errs, apperrors.FieldError{Field: "start_date", Code: apperrors.InvalidDate.Code, Message: apperrors.InvalidDate.Message})
+	}
+	sub.StartDate = types.MonthYear(startDate)
+
+	if endValue := value("end_date"); len(endValue) != 0 {
+		endDate, err := time.Parse("01-2006", endValue)
+		if err != nil {
+			errs = append(errs, apperrors.FieldError{Field: "end_date", Code: apperrors.InvalidDate.Code, Message: apperrors.InvalidDate.Message})
+		}
+		month := types.MonthYear(endDate)
+		sub.EndDate = &month
+	}
+
+	sub.BillingPeriod = value("billing_period")
+
+	if anchorValue := value("billing_anchor"); len(anchorValue) != 0 {
+		anchor, err := time.Parse(time.DateOnly, anchorValue)
+		if err != nil {
+			errs = append(errs, apperrors.FieldError{Field: "billing_anchor", Code: apperrors.InvalidDate.Code, Message: "must be in YYYY-MM-DD format"})
+		}
+		date := types.Date(anchor)
+		sub.BillingAnchor = &date
+	}
+
+	return sub, errs
+}
+
+// readImportRows reads the header and every row of the CSV body. A record
+// that cannot be parsed is returned as a malformed row and reading goes on.
+func readImportRows(body io.Reader) ([]types.ImportRow, error) {
+	cr := csv.NewReader(body)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+	cr.ReuseRecord = true
+
+	columns, err := readImportHeader(cr)
+	if err != nil {
+		return nil, err
+	}
+
+	rows := make([]types.ImportRow, 0)
+
+	for {
+		record, err := cr.Read()
+		if errors.Is(err, io.EOF) {
+			return rows, nil
+		}
+
+		var row types.ImportRow
+		var parseErr *csv.ParseError
+
+		switch {
+		case errors.As(err, &parseErr):
+			row = types.ImportRow{
+				Line:      parseErr.StartLine,
+				Errors:    []apperrors.FieldError{{Field: "row", Code: "malformed", Message: parseErr.Err.Error()}},
+				Malformed: true,
+			}
+		case err != nil:
+			return nil, importReadError(err)
+		default:
+			row.Line, _ = cr.FieldPos(0)
+			row.Subscription, row.Errors = parseImportRecord(record, columns)
+		}
+
+		if len(rows) == validation.MaxImportRows {
+			return nil, tooManyImportRows
+		}
+		rows = append(rows, row)
+	}
+}
+
+// ImportSubscriptions godoc
+// @Summary Import subscriptions from CSV
+// @Description Create subscriptions from a CSV file with a header of service_name, user_id, start_date and optional
+// @Description price, end_date, currency, billing_period and billing_anchor columns, dates are in MM-YYYY format
+// @Description and billing_anchor in YYYY-MM-DD. Price may be empty when the service has a default price.
+// @Description The file is limited to 10000 rows and 4 MiB and is read whole before it is imported in one
+// @Description transaction: every row is validated like a created subscription, all problems of all rows are
+// @Description reported, and the rows are committed only when all of them are valid. With dry_run the file is
+// @Description only validated.
+// @Tags subscriptions
+// @Accept text/csv
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param dry_run query bool false "Only validate the file"
+// @Param request body string true "CSV file"
+// @Success 200 {object} types.ImportResponse "Dry run without errors"
+// @Success 201 {object} types.ImportResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 415 {object} responses.Problem
+// @Failure 422 {object} types.ImportResponse
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/import [post]
+func (sr *SubscriptionsRoutes) ImportSubscriptions(w http.ResponseWriter, r *http.Request) {
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "text/csv" {
+		responses.SetError(w, apperrors.UnsupportedMediaType)
+		return
+	}
+
+	dryRun := false
+
+	if q := r.URL.Query(); q.Has("dry_run") {
+		var err error
+		if dryRun, err = strconv.ParseBool(q.Get("dry_run")); err != nil {
+			responses.SetError(w, apperrors.InvalidParam("dry_run", "must be a boolean"))
+			return
+		}
+	}
+
+	// The body is read before the import transaction is opened, so that a slow
+	// client does not keep it open.
+	rows, err := readImportRows(http.MaxBytesReader(w, r.Body, validation.MaxImportBytes))
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	res, err := sr.uc.ImportSubscriptions(rows, dryRun, actor(r))
+	if err != nil {
+		writeError(w, sr.logger, "Repo failed on import", err, slog.Bool("dry_run", dryRun))
+		return
+	}
+
+	switch {
+	case res.Invalid != 0:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	case res.Committed:
+		w.WriteHeader(http.StatusCreated)
+	}
+
+	err = responses.SetJsonBody(w, res)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", res), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,202 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/validation"
+	"testing"
+	"time"
+)
+
+// fieldCodes returns the "field:code" pairs of field errors.
+func fieldCodes(errs []apperrors.FieldError) []string {
+	res := make([]string, len(errs))
+	for i, e := range errs {
+		res[i] = e.Field + ":" + e.Code
+	}
+	return res
+}
+
+func TestReadImportHeader(t *testing.T) {
+	tests := []struct {
+		name   string
+		header string
+		want   map[string]int
+		errs   []string
+	}{
+		{
+			name:   "required columns",
+			header: "service_name,user_id,start_date\n",
+			want:   map[string]int{"service_name": 0, "user_id": 1, "start_date": 2},
+		},
+		{
+			name:   "any order, case and spaces",
+			header: " Price , START_DATE,user_id,service_name\n",
+			want:   map[string]int{"price": 0, "start_date": 1, "user_id": 2, "service_name": 3},
+		},
+		{
+			name:   "byte order mark",
+			header: "\ufeffservice_name,user_id,start_date\n",
+			want:   map[string]int{"service_name": 0, "user_id": 1, "start_date": 2},
+		},
+		{name: "empty", header: "", errs: []string{"header:required"}},
+		{
+			name:   "unknown column",
+			header: "service_name,user_id,start_date,cost\n",
+			errs:   []string{"header:unknown_column"},
+		},
+		{
+			name:   "missing columns",
+			header: "service_name,price\n",
+			errs:   []string{"header:missing_column", "header:missing_column"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := readImportHeader(csv.NewReader(strings.NewReader(tt.header)))
+
+			if tt.errs != nil {
+				appErr, ok := apperrors.As(err)
+				if !ok || !slices.Equal(fieldCodes(appErr.Fields), tt.errs) {
+					t.Fatalf("readImportHeader(%q) error = %v, want %v", tt.header, err, tt.errs)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("readImportHeader(%q) unexpected error: %v", tt.header, err)
+			}
+
+			if len(got) != len(tt.want) {
+				t.Fatalf("readImportHeader(%q) = %v, want %v", tt.header, got, tt.want)
+			}
+			for name, i := range tt.want {
+				if got[name] != i {
+					t.Errorf("readImportHeader(%q) = %v, want %v", tt.header, got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestParseImportRecord(t *testing.T) {
+	columns := map[string]int{
+		"service_name": 0, "user_id": 1, "start_date": 2, "price": 3,
+		"end_date": 4, "currency": 5, "billing_period": 6, "billing_anchor": 7,
+	}
+	const user = "60601fee-2bf1-4721-ae6f-7636e79a0cba"
+
+	tests := []struct {
+		name   string
+		record []string
+		errs   []string
+	}{
+		{name: "valid", record: []string{"Netflix", user, "01-2025", "999", "12-2025", "usd", "yearly", "2025-01-15"}},
+		{name: "optional values empty", record: []string{"Netflix", user, "01-2025", "", "", "", "", ""}},
+		{name: "short record", record: []string{"Netflix", user, "01-2025"}},
+		{name: "price not an int", record: []string{"Netflix", user, "01-2025", "9.99"}, errs: []string{"price:invalid_type"}},
+		{name: "user not a uuid", record: []string{"Netflix", "42", "01-2025"}, errs: []string{"user_id:invalid_type"}},
+		{name: "start date missing", record: []string{"Netflix", user, ""}, errs: []string{"start_date:invalid_date"}},
+		{name: "start date as a day", record: []string{"Netflix", user, "2025-01-01"}, errs: []string{"start_date:invalid_date"}},
+		{name: "end date month 13", record: []string{"Netflix", user, "01-2025", "1", "13-2025"}, errs: []string{"end_date:invalid_date"}},
+		{
+			name:   "anchor as a month",
+			record: []string{"Netflix", user, "01-2025", "1", "", "", "", "01-2025"},
+			errs:   []string{"billing_anchor:invalid_date"},
+		},
+		{
+			name:   "every bad value",
+			record: []string{"Netflix", "x", "x", "x", "x", "", "", "x"},
+			errs:   []string{"price:invalid_type", "user_id:invalid_type", "start_date:invalid_date", "end_date:invalid_date", "billing_anchor:invalid_date"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, errs := parseImportRecord(tt.record, columns)
+
+			if got := fieldCodes(errs); !slices.Equal(got, tt.errs) {
+				t.Errorf("parseImportRecord(%q) errors = %v, want %v", tt.record, got, tt.errs)
+			}
+		})
+	}
+
+	sub, _ := parseImportRecord([]string{" Netflix ", user, "01-2025", "999", "12-2025", "usd", "yearly", "2025-01-15"}, columns)
+
+	if sub.ServiceName != "Netflix" || sub.UserID.String() != user || *sub.Price != 999 ||
+		!time.Time(sub.StartDate).Equal(time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)) ||
+		!time.Time(*sub.EndDate).Equal(time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)) ||
+		sub.Currency != "usd" || sub.BillingPeriod != "yearly" ||
+		!time.Time(*sub.BillingAnchor).Equal(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("parseImportRecord = %+v, want every value parsed", sub)
+	}
+}
+
+func TestReadImportRows(t *testing.T) {
+	const header = "service_name,user_id,start_date,price\n"
+	const row = "Netflix,60601fee-2bf1-4721-ae6f-7636e79a0cba,01-2025,999\n"
+
+	tests := []struct {
+		name      string
+		body      string
+		limit     int64
+		wantRows  int
+		errs      []string
+		malformed []int
+	}{
+		{name: "header only", body: header},
+		{name: "rows", body: header + row + row, wantRows: 2},
+		{name: "row limit", body: header + strings.Repeat(row, validation.MaxImportRows), wantRows: validation.MaxImportRows},
+		{name: "over the row limit", body: header + strings.Repeat(row, validation.MaxImportRows+1), errs: []string{"body:too_many"}},
+		{
+			name:      "malformed row goes on",
+			body:      header + row + "Netflix,\"bad\"quote,01-2025,1\n" + row,
+			wantRows:  3,
+			malformed: []int{3},
+		},
+		{name: "too large", body: header + row + row, limit: int64(len(header) + len(row)), errs: []string{"body:too_large"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit := tt.limit
+			if limit == 0 {
+				limit = int64(len(tt.body))
+			}
+			body := httptest.NewRequest("POST", "/subscriptions/import", strings.NewReader(tt.body)).Body
+
+			rows, err := readImportRows(http.MaxBytesReader(httptest.NewRecorder(), body, limit))
+
+			if tt.errs != nil {
+				appErr, ok := apperrors.As(err)
+				if !ok || !slices.Equal(fieldCodes(appErr.Fields), tt.errs) {
+					t.Fatalf("readImportRows error = %v, want %v", err, tt.errs)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("readImportRows unexpected error: %v", err)
+			}
+
+			if len(rows) != tt.wantRows {
+				t.Fatalf("readImportRows = %d rows, want %d", len(rows), tt.wantRows)
+			}
+
+			var malformed []int
+			for _, row := range rows {
+				if row.Malformed {
+					malformed = append(malformed, row.Line)
+				}
+			}
+			if !slices.Equal(malformed, tt.malformed) {
+				t.Errorf("malformed rows at lines %v, want %v", malformed, tt.malformed)
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/usecases"
+
+	"github.com/go-chi/chi/v5"
+)
+
+type ServicesRoutes struct {
+	uc     usecases.ServiceUseCases
+	logger *slog.Logger
+}
+
+func NewServicesRoutes(uc usecases.ServiceUseCases, logger *slog.Logger) ServicesRoutes {
+	return ServicesRoutes{uc, logger}
+}
+
+func (sv *ServicesRoutes) RegisterRoutes(r chi.Router) {
+	r.Route("/services", func(r chi.Router) {
+		r.Post("/", sv.CreateService)
+		r.Get("/", sv.GetServices)
+		r.Get("/{id}", sv.GetService)
+		r.Put("/{id}", sv.UpdateService)
+		r.Delete("/{id}", sv.DeleteService)
+	})
+}
+
+// CreateService godoc
+// @Summary Create service
+// @Description Create a catalog service. Subscriptions refer to it by id or by its name or an alias, ignoring case.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param request body types.ServiceRequest true "Service data"
+// @Success 201 {object} types.Service
+// @Failure 400 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /services [post]
+func (sv *ServicesRoutes) CreateService(w http.ResponseWriter, r *http.Request) {
+	var serviceReq types.ServiceRequest
+
+	if err := decodeJSON(r, &serviceReq); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	service, err := sv.uc.SaveService(serviceReq)
+	if err != nil {
+		writeError(w, sv.logger, "Repo failed on service create", err, slog.Any("obj", serviceReq))
+		return
+	}
+
+	w.WriteHeader(http.StatusCreated)
+	err = responses.SetJsonBody(w, service)
+
+	if err != nil {
+		sv.logger.Error("Json set body", slog.Any("obj", service), slog.Any("err", err))
+	}
+}
+
+// GetServices godoc
+// @Summary Get services list
+// @Description Get all catalog services ordered by name
+// @Tags services
+// @Produce json
+// @Success 200 {array} types.Service
+// @Failure 500 {object} responses.Problem
+// @Router /services [get]
+func (sv *ServicesRoutes) GetServices(w http.ResponseWriter, r *http.Request) {
+	services, err := sv.uc.GetServices()
+
+	if err != nil {
+		writeError(w, sv.logger, "Repo Get services", err)
+		return
+	}
+
+	err = responses.SetJsonBody(w, services)
+
+	if err != nil {
+		sv.logger.Error("Json set body", slog.Any("obj", services), slog.Any("err", err))
+	}
+}
+
+// GetService godoc
+// @Summary Get service by ID
+// @Description Get catalog service by ID
+// @Tags services
+// @Produce json
+// @Param id path int true "Service ID"
+// @Success 200 {object} types.Service
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /services/{id} [get]
+func (sv *ServicesRoutes) GetService(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	service, err := sv.uc.GetService(id)
+
+	if err != nil {
+		writeError(w, sv.logger, "Repo Get service", err, slog.Int("id", id))
+		return
+	}
+
+	err = responses.SetJsonBody(w, service)
+
+	if err != nil {
+		sv.logger.Error("Json set body", slog.Any("obj", service), slog.Any("err", err))
+	}
+}
+
+// UpdateService godoc
+// @Summary Update service
+// @Description Replace catalog service by ID. Renamed service subscriptions get the new name and version, and the
+// @Description rename is recorded in their history.
+// @Tags services
+// @Accept json
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the history of renamed subscriptions"
+// @Param id path int true "Service ID"
+// @Param request body types.ServiceRequest true "Service data"
+// @Success 200 {object} types.Service
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /services/{id} [put]
+func (sv *ServicesRoutes) UpdateService(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	var serviceReq types.ServiceRequest
+
+	if err := decodeJSON(r, &serviceReq); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	service, err := sv.uc.UpdateService(id, serviceReq, actor(r))
+
+	if err != nil {
+		writeError(w, sv.logger, "Repo Update service", err, slog.Int("id", id), slog.Any("obj", serviceReq))
+		return
+	}
+
+	err = responses.SetJsonBody(w, service)
+
+	if err != nil {
+		sv.logger.Error("Json set body", slog.Any("obj", service), slog.Any("err", err))
+	}
+}
+
+// DeleteService godoc
+// @Summary Delete service
+// @Description Delete catalog service by ID. Services with subscriptions, deleted ones included, cannot be deleted.
+// @Tags services
+// @Param id path int true "Service ID"
+// @Success 204
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /services/{id} [delete]
+func (sv *ServicesRoutes) DeleteService(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	if err := sv.uc.DeleteService(id); err != nil {
+		writeError(w, sv.logger, "Repo Delete service", err, slog.Int("id", id))
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+)
+
+// GetTotalStats godoc
+// @Summary Get total subscription stats
+// @Description Get total cost of subscriptions over a period: each subscription contributes its price for every
+// @Description billing event of its billing period within the months of [start_date, end_date], charged at the price
+// @Description in effect in the month of the event, less the trial or discount of that month. Months the subscription
+// @Description is paused in are not charged. Without end_date the period ends at the current month. Prices in other currencies are converted with the exchange rates,
+// @Description the request fails when a rate is missing.
+// @Tags subscriptions
+// @Produce json
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Service name"
+// @Param start_date query string false "Start date (MM-YYYY)"
+// @Param end_date query string false "End date (MM-YYYY)"
+// @Param currency query string false "ISO 4217 currency the amounts are converted to, RUB by default" example(USD)
+// @Success 200 {object} types.TotalStatsResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/total [get]
+func (sr *SubscriptionsRoutes) GetTotalStats(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseStatsFilter(r.URL.Query())
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	total, err := sr.uc.GetTotalStats(filter)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get total", err, slog.Any("filter", filter))
+		return
+	}
+
+	err = responses.SetJsonBody(w, total)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", total), slog.Any("err", err))
+	}
+}
+
+// GetCostBreakdown godoc
+// @Summary Get subscription cost breakdown
+// @Description Get total cost and subscriptions count per service, user or month, computed the same way as /subscriptions/total
+// @Tags subscriptions
+// @Produce json
+// @Param group_by query string true "Grouping key" Enums(service_name, user_id, month)
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Service name"
+// @Param start_date query string false "Start date (MM-YYYY)"
+// @Param end_date query string false "End date (MM-YYYY)"
+// @Param currency query string false "ISO 4217 currency the amounts are converted to, RUB by default" example(USD)
+// @Success 200 {object} types.BreakdownResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/stats/breakdown [get]
+func (sr *SubscriptionsRoutes) GetCostBreakdown(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	groupBy := q.Get("group_by")
+	if !types.IsValidGroupBy(groupBy) {
+		responses.SetError(w, apperrors.InvalidParam("group_by", "must be one of service_name, user_id, month"))
+		return
+	}
+
+	filter, err := parseStatsFilter(q)
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	breakdown, err := sr.uc.GetCostBreakdown(groupBy, filter)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get breakdown", err, slog.String("group_by", groupBy), slog.Any("filter", filter))
+		return
+	}
+
+	err = responses.SetJsonBody(w, breakdown)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", breakdown), slog.Any("err", err))
+	}
+}
+
+// GetTimeSeries godoc
+// @Summary Get monthly spend time series
+// @Description Get total cost and active subscriptions count for every month of [from, to], computed the same way
+// @Description as /subscriptions/total. Months without charges are included with zero values.
+// @Tags subscriptions
+// @Produce json
+// @Param from query string true "First month (MM-YYYY)"
+// @Param to query string true "Last month (MM-YYYY)"
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Service name"
+// @Param currency query string false "ISO 4217 currency the amounts are converted to, RUB by default" example(USD)
+// @Success 200 {object} types.TimeSeriesResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/stats/timeseries [get]
+func (sr *SubscriptionsRoutes) GetTimeSeries(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := types.StatsFilter{ServiceName: q.Get("service_name")}
+
+	var err error
+
+	if filter.UserID, err = parseUserIDParam(q, "user_id"); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	if filter.StartDate, err = parseRequiredMonthParam(q, "from"); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	if filter.EndDate, err = parseRequiredMonthParam(q, "to"); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	if filter.Currency, err = parseCurrencyParam(q, "currency"); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	series, err := sr.uc.GetTimeSeries(filter)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get time series", err, slog.Any("filter", filter))
+		return
+	}
+
+	err = responses.SetJsonBody(w, series)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", series), slog.Any("err", err))
+	}
+}
+
+// defaultForecastMonths is the forecast length when months is omitted.
+const defaultForecastMonths = 12
+
+// GetForecast godoc
+// @Summary Get charges forecast
+// @Description Project the charges of the next months, the current one included, from the subscriptions active
+// @Description in them: each renewal of a billing period is charged at the price scheduled for its month, less
+// @Description its trial or discount, and subscriptions stop being charged after their end_date.
+// @Tags subscriptions
+// @Produce json
+// @Param months query int false "Number of months, 12 by default" minimum(1) maximum(60)
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Service name"
+// @Param currency query string false "ISO 4217 currency the amounts are converted to, RUB by default" example(USD)
+// @Success 200 {object} types.ForecastResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/forecast [get]
+func (sr *SubscriptionsRoutes) GetForecast(w http.ResponseWriter, r *http.Request) {
+	q := r.URL.Query()
+
+	filter := types.StatsFilter{ServiceName: q.Get("service_name")}
+	months := defaultForecastMonths
+
+	var err error
+
+	if q.Has("months") {
+		if months, err = parsePositiveParam(q, "months"); err != nil {
+			responses.SetError(w, err)
+			return
+		}
+	}
+
+	if filter.UserID, err = parseUserIDParam(q, "user_id"); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	if filter.Currency, err = parseCurrencyParam(q, "currency"); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	forecast, err := sr.uc.GetForecast(months, filter)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get forecast", err, slog.Int("months", months), slog.Any("filter", filter))
+		return
+	}
+
+	err = responses.SetJsonBody(w, forecast)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", forecast), slog.Any("err", err))
+	}
+}
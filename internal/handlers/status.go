@@ -0,0 +1,138 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+)
+
+// transition handles a status transition request of the subscription in the path.
+func (sr *SubscriptionsRoutes) transition(
+	w http.ResponseWriter,
+	r *http.Request,
+	msg string,
+	fn func(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error),
+) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, err := fn(id, expectedVersion, actor(r))
+
+	if err != nil {
+		writeError(w, sr.logger, msg, err, slog.Int("id", id))
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+
+	err = responses.SetJsonBody(w, sub)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
+	}
+}
+
+// PauseSubscription godoc
+// @Summary Pause subscription
+// @Description Pause an active subscription. It is not charged from the current month, or from its start if it has
+// @Description not started yet, until it is resumed.
+// @Tags subscriptions
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/pause [post]
+func (sr *SubscriptionsRoutes) PauseSubscription(w http.ResponseWriter, r *http.Request) {
+	sr.transition(w, r, "Repo Pause sub", sr.uc.PauseSubscription)
+}
+
+// ResumeSubscription godoc
+// @Summary Resume subscription
+// @Description Resume a paused subscription, it is charged again from the current month.
+// @Tags subscriptions
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/resume [post]
+func (sr *SubscriptionsRoutes) ResumeSubscription(w http.ResponseWriter, r *http.Request) {
+	sr.transition(w, r, "Repo Resume sub", sr.uc.ResumeSubscription)
+}
+
+// CancelSubscription godoc
+// @Summary Cancel subscription
+// @Description Cancel an active or paused subscription. Its end_date is set to the current month unless it ends
+// @Description earlier, and it cannot be paused or resumed anymore. A subscription that has not started yet ends with
+// @Description its start month and is paused from it, so nothing is charged.
+// @Tags subscriptions
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/cancel [post]
+func (sr *SubscriptionsRoutes) CancelSubscription(w http.ResponseWriter, r *http.Request) {
+	sr.transition(w, r, "Repo Cancel sub", sr.uc.CancelSubscription)
+}
+
+// GetPauses godoc
+// @Summary Get subscription pauses
+// @Description Get subscription pauses ordered by the month they start, end_month is null while it is paused
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} types.PausePeriod
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/pauses [get]
+func (sr *SubscriptionsRoutes) GetPauses(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	pauses, err := sr.uc.GetPauses(id)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get pauses", err, slog.Int("id", id))
+		return
+	}
+
+	err = responses.SetJsonBody(w, pauses)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", pauses), slog.Any("err", err))
+	}
+}
@@ -1,13 +1,10 @@
 package handlers
 
 import (
-	"encoding/json"
-	"errors"
-	"io"
 	"log/slog"
+	"mime"
 	"net/http"
-	"strconv"
-	"time"
+	"net/url"
 
 	"subscriptions-api/internal/apperrors"
 	"subscriptions-api/internal/responses"
@@ -30,10 +27,38 @@ func (sr *SubscriptionsRoutes) RegisterRoutes(r chi.Router) {
 	r.Route("/subscriptions", func(r chi.Router) {
 		r.Post("/", sr.CreateSubscription)
 		r.Get("/", sr.GetSubscriptions)
+		r.Get("/trash", sr.GetDeletedSubscriptions)
+		r.Get("/active", sr.GetActiveSubscriptions)
+		r.Post("/batch", sr.CreateSubscriptions)
+		r.Put("/batch", sr.UpdateSubscriptions)
+		r.Delete("/batch", sr.DeleteSubscriptions)
+		r.Post("/import", sr.ImportSubscriptions)
+		r.Get("/export", sr.ExportSubscriptions)
 		r.Get("/{id}", sr.GetSubscription)
 		r.Put("/{id}", sr.UpdateSubscription)
+		r.Patch("/{id}", sr.PatchSubscription)
 		r.Delete("/{id}", sr.DeleteSubscription)
+		r.Post("/{id}/restore", sr.RestoreSubscription)
+		r.Post("/{id}/pause", sr.PauseSubscription)
+		r.Post("/{id}/resume", sr.ResumeSubscription)
+		r.Post("/{id}/cancel", sr.CancelSubscription)
+		r.Get("/{id}/pauses", sr.GetPauses)
+		r.Get("/{id}/history", sr.GetSubscriptionHistory)
+		r.Post("/{id}/price-changes", sr.AddPriceChange)
+		r.Get("/{id}/price-changes", sr.GetPriceHistory)
+		r.Post("/{id}/discounts", sr.AddDiscount)
+		r.Get("/{id}/discounts", sr.GetDiscounts)
+		r.Delete("/{id}/discounts/{discount_id}", sr.DeleteDiscount)
 		r.Get("/total", sr.GetTotalStats)
+		r.Get("/stats/breakdown", sr.GetCostBreakdown)
+		r.Get("/stats/breakdown/export", sr.ExportCostBreakdown)
+		r.Get("/stats/timeseries", sr.GetTimeSeries)
+		r.Get("/forecast", sr.GetForecast)
+	})
+
+	r.Route("/users/{user_id}", func(r chi.Router) {
+		r.Get("/subscriptions", sr.GetUserSubscriptions)
+		r.Get("/total", sr.GetUserTotal)
 	})
 }
 
@@ -43,82 +68,202 @@ func (sr *SubscriptionsRoutes) RegisterRoutes(r chi.Router) {
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
 // @Param request body types.SubscriptionRequest true "Subscription data"
 // @Success 201 {object} types.SubscriptionResponse
-// @Failure 400 {string} string
-// @Failure 500 {string} string
+// @Header 201 {string} ETag "Subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /subscriptions [post]
 func (sr *SubscriptionsRoutes) CreateSubscription(w http.ResponseWriter, r *http.Request) {
-	body, err := io.ReadAll(r.Body)
-
-	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-		return
-	}
-
 	var subReq types.SubscriptionRequest
-	err = json.Unmarshal(body, &subReq)
-	if err != nil || subReq.Price < 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+
+	if err := decodeJSON(r, &subReq); err != nil {
+		responses.SetError(w, err)
 		return
 	}
 
-	sub, err := sr.uc.SaveSubscription(subReq)
+	sub, err := sr.uc.SaveSubscription(subReq, actor(r))
 	if err != nil {
-		sr.logger.Error("Repo failed on create", slog.Any("obj", subReq), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		writeError(w, sr.logger, "Repo failed on create", err, slog.Any("obj", subReq))
 		return
 	}
 
+	w.Header().Set("ETag", etag(sub.Version))
 	w.WriteHeader(http.StatusCreated)
 	err = responses.SetJsonBody(w, sub)
 
 	if err != nil {
 		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	}
 }
 
 // GetSubscriptions godoc
 // @Summary Get subscriptions list
-// @Description Get paginated list of subscriptions
+// @Description Get filtered and sorted list of subscriptions. With page the list is paginated by offset and returned
+// @Description in a types.SubscriptionsPageResponse envelope with Link and X-Total-Count headers, otherwise
+// @Description keyset pagination is used and types.SubscriptionsCursorResponse is returned: pass its next_cursor
+// @Description as cursor to get the next page. The cursor is only valid for the sort it was issued with.
 // @Tags subscriptions
 // @Produce json
-// @Param page query int true "Page number"
-// @Param count query int true "Items per page"
-// @Success 200 {array} types.SubscriptionResponse
-// @Failure 400 {string} string
-// @Failure 500 {string} string
+// @Param page query int false "Page number"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param count query int true "Items per page, at most 1000"
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Case-insensitive service name prefix"
+// @Param price_min query int false "Minimal price"
+// @Param price_max query int false "Maximal price"
+// @Param start_from query string false "Earliest start date (MM-YYYY)"
+// @Param start_to query string false "Latest start date (MM-YYYY)"
+// @Param active_at query string false "Month the subscriptions are active in (MM-YYYY)"
+// @Param status query string false "Subscription status" Enums(active, paused, cancelled)
+// @Param sort query string false "Comma separated sort fields, minus for descending order: id, service_name, price, user_id, start_date, end_date" example(price,-start_date)
+// @Param include_deleted query bool false "Include soft-deleted subscriptions"
+// @Success 200 {object} types.SubscriptionsPageResponse
+// @Header 200 {string} Link "Links to the first, previous, next and last pages"
+// @Header 200 {integer} X-Total-Count "Total number of matching subscriptions"
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /subscriptions [get]
 func (sr *SubscriptionsRoutes) GetSubscriptions(w http.ResponseWriter, r *http.Request) {
+	sr.listSubscriptions(w, r, nil)
+}
+
+// GetDeletedSubscriptions godoc
+// @Summary Get deleted subscriptions list
+// @Description Get soft-deleted subscriptions, which can be restored. Takes the same parameters as the subscriptions list.
+// @Tags subscriptions
+// @Produce json
+// @Param page query int false "Page number"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param count query int true "Items per page, at most 1000"
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Case-insensitive service name prefix"
+// @Param sort query string false "Comma separated sort fields, minus for descending order" example(-id)
+// @Success 200 {object} types.SubscriptionsPageResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/trash [get]
+func (sr *SubscriptionsRoutes) GetDeletedSubscriptions(w http.ResponseWriter, r *http.Request) {
+	sr.listSubscriptions(w, r, func(filter *types.SubscriptionsFilter) {
+		filter.Deleted = types.OnlyDeleted
+	})
+}
+
+// GetActiveSubscriptions godoc
+// @Summary Get active subscriptions list
+// @Description Get subscriptions active in a month: started on or before it, not ended before it and not paused in it,
+// @Description the same subscriptions the active counts of the stats count.
+// @Description Takes the same parameters as the subscriptions list.
+// @Tags subscriptions
+// @Produce json
+// @Param active_at query string false "Month (MM-YYYY), the current month by default"
+// @Param page query int false "Page number"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param count query int true "Items per page, at most 1000"
+// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Case-insensitive service name prefix"
+// @Param sort query string false "Comma separated sort fields, minus for descending order" example(-start_date)
+// @Success 200 {object} types.SubscriptionsPageResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/active [get]
+func (sr *SubscriptionsRoutes) GetActiveSubscriptions(w http.ResponseWriter, r *http.Request) {
+	sr.listSubscriptions(w, r, func(filter *types.SubscriptionsFilter) {
+		if filter.ActiveAt == nil {
+			month := types.CurrentMonth()
+			filter.ActiveAt = &month
+		}
+	})
+}
+
+// listSubscriptions serves a subscriptions list, scope narrows the filter parsed from the query.
+func (sr *SubscriptionsRoutes) listSubscriptions(w http.ResponseWriter, r *http.Request, scope func(filter *types.SubscriptionsFilter)) {
 	q := r.URL.Query()
-	page, err := strconv.Atoi(q.Get("page"))
+	count, err := parsePositiveParam(q, "count")
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	filter, err := parseSubscriptionsFilter(q)
 
-	if err != nil || page <= 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err != nil {
+		responses.SetError(w, err)
 		return
 	}
 
-	count, err := strconv.Atoi(q.Get("count"))
+	if scope != nil {
+		scope(&filter)
+	}
+
+	sort, err := types.ParseSort(q.Get("sort"))
 
-	if err != nil || count <= 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err != nil {
+		responses.SetError(w, apperrors.InvalidParam("sort", err.Error()))
+		return
+	}
+
+	if !q.Has("page") {
+		sr.getSubscriptionsByCursor(w, r.URL, filter, sort, count)
+		return
+	}
+
+	page, err := parsePositiveParam(q, "page")
+
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	subs, err := sr.uc.GetSubscriptions(filter, sort, page, count)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get subs", err, slog.Int("page", page), slog.Int("count", count), slog.Any("filter", filter))
 		return
 	}
 
-	subs, err := sr.uc.GetSubscriptions(page, count)
+	setPageLinks(w, r.URL, &subs)
+
+	err = responses.SetJsonBody(w, subs)
 
 	if err != nil {
-		sr.logger.Error("Repo Get subs", slog.Int("page", page), slog.Int("count", count), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		sr.logger.Error("Json set body", slog.Any("obj", subs), slog.Any("err", err))
+	}
+}
+
+func (sr *SubscriptionsRoutes) getSubscriptionsByCursor(w http.ResponseWriter, u *url.URL, filter types.SubscriptionsFilter, sort []types.SortField, count int) {
+	var cursor *types.Cursor
+	rawCursor := u.Query().Get("cursor")
+
+	if len(rawCursor) != 0 {
+		c, err := types.DecodeCursor(rawCursor, sort)
+		if err != nil {
+			responses.SetError(w, apperrors.InvalidParam("cursor", "must be a next_cursor issued for the same sort"))
+			return
+		}
+		cursor = &c
+	}
+
+	subs, err := sr.uc.GetSubscriptionsByCursor(filter, sort, cursor, count)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get subs by cursor", err, slog.String("cursor", rawCursor), slog.Int("count", count), slog.Any("filter", filter))
 		return
 	}
 
+	if len(subs.NextCursor) != 0 {
+		w.Header().Set("Link", linkHeader(map[string]string{"next": withQueryParam(u, "cursor", subs.NextCursor)}))
+	}
+
 	err = responses.SetJsonBody(w, subs)
 
 	if err != nil {
 		sr.logger.Error("Json set body", slog.Any("obj", subs), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	}
 }
 
@@ -129,119 +274,186 @@ func (sr *SubscriptionsRoutes) GetSubscriptions(w http.ResponseWriter, r *http.R
 // @Produce json
 // @Param id path int true "Subscription ID"
 // @Success 200 {object} types.SubscriptionResponse
-// @Failure 400 {string} string
-// @Failure 404 {string} string
-// @Failure 500 {string} string
+// @Header 200 {string} ETag "Subscription version, pass it in If-Match to update or delete the subscription"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /subscriptions/{id} [get]
 func (sr *SubscriptionsRoutes) GetSubscription(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	id, err := parseID(r)
 
-	if err != nil || id <= 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err != nil {
+		responses.SetError(w, err)
 		return
 	}
 
 	sub, err := sr.uc.GetSubscription(id)
 
 	if err != nil {
-		if errors.Is(err, apperrors.SubscriptionNotFound) {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		} else {
-			sr.logger.Error("Repo Get sub", slog.Int("id", id), slog.Any("err", err))
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+		writeError(w, sr.logger, "Repo Get sub", err, slog.Int("id", id))
 		return
 	}
 
+	w.Header().Set("ETag", etag(sub.Version))
+
 	err = responses.SetJsonBody(w, sub)
 
 	if err != nil {
 		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	}
 }
 
 // UpdateSubscription godoc
 // @Summary Update subscription
-// @Description Update subscription by ID
+// @Description Update subscription by ID. The start and end dates of a paused or cancelled subscription cannot change.
+// @Description A new price, currency, billing period or anchor is charged from the current month on and replaces
+// @Description the price changes scheduled after it; earlier months keep the terms they were charged on.
 // @Tags subscriptions
 // @Accept json
 // @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
 // @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
 // @Param request body types.SubscriptionRequest true "Updated subscription data"
 // @Success 200 {object} types.SubscriptionResponse
-// @Failure 400 {string} string
-// @Failure 404 {string} string
-// @Failure 500 {string} string
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /subscriptions/{id} [put]
 func (sr *SubscriptionsRoutes) UpdateSubscription(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
-	if err != nil || id <= 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
 		return
 	}
 
-	body, err := io.ReadAll(r.Body)
+	expectedVersion, err := parseIfMatch(r)
 	if err != nil {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+		responses.SetError(w, err)
 		return
 	}
 
 	var subReq types.SubscriptionRequest
-	err = json.Unmarshal(body, &subReq)
 
-	if err != nil || subReq.Price < 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	if err := decodeJSON(r, &subReq); err != nil {
+		responses.SetError(w, err)
 		return
 	}
 
-	sub, err := sr.uc.UpdateSubscription(id, subReq)
+	sub, err := sr.uc.UpdateSubscription(id, subReq, expectedVersion, actor(r))
 
 	if err != nil {
-		if errors.Is(err, apperrors.SubscriptionNotFound) {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		} else {
-			sr.logger.Error("Repo Update sub", slog.Int("id", id), slog.Any("obj", sub), slog.Any("err", err))
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+		writeError(w, sr.logger, "Repo Update sub", err, slog.Int("id", id), slog.Any("obj", subReq))
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+
+	err = responses.SetJsonBody(w, sub)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
+	}
+}
+
+// PatchSubscription godoc
+// @Summary Partially update subscription
+// @Description Update subscription by ID with a JSON Merge Patch (RFC 7396): omitted fields are left unchanged,
+// @Description end_date is removed with an explicit null. The start and end dates of a paused or cancelled subscription
+// @Description cannot change. A new price, currency, billing period or anchor is charged from the current month on
+// @Description and replaces the price changes scheduled after it; earlier months keep the terms they were charged on.
+// @Tags subscriptions
+// @Accept application/merge-patch+json,json
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Param request body types.SubscriptionPatch true "Fields to change"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 409 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 415 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id} [patch]
+func (sr *SubscriptionsRoutes) PatchSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType != "application/merge-patch+json" && mediaType != "application/json" {
+		responses.SetError(w, apperrors.UnsupportedMediaType)
+		return
+	}
+
+	var patch types.SubscriptionPatch
+
+	if err := decodeJSON(r, &patch); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, err := sr.uc.PatchSubscription(id, patch, expectedVersion, actor(r))
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Patch sub", err, slog.Int("id", id), slog.Any("obj", patch))
 		return
 	}
 
+	w.Header().Set("ETag", etag(sub.Version))
+
 	err = responses.SetJsonBody(w, sub)
 
 	if err != nil {
 		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	}
 }
 
 // DeleteSubscription godoc
 // @Summary Delete subscription
-// @Description Delete subscription by ID
+// @Description Soft-delete subscription by ID. It is hidden from lists and stats until restored.
 // @Tags subscriptions
 // @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
 // @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
 // @Success 200 {object} types.SubscriptionResponse
-// @Failure 400 {string} string
-// @Failure 404 {string} string
-// @Failure 500 {string} string
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
 // @Router /subscriptions/{id} [delete]
 func (sr *SubscriptionsRoutes) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
-	id, err := strconv.Atoi(chi.URLParam(r, "id"))
-	if err != nil || id <= 0 {
-		http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
 		return
 	}
 
-	sub, err := sr.uc.DeleteSubscriptions(id)
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, err := sr.uc.DeleteSubscriptions(id, expectedVersion, actor(r))
 
 	if err != nil {
-		sr.logger.Error("Repo Update sub", slog.Int("id", id), slog.Any("obj", sub), slog.Any("err", err))
-		if errors.Is(err, apperrors.SubscriptionNotFound) {
-			http.Error(w, http.StatusText(http.StatusNotFound), http.StatusNotFound)
-		} else {
-			http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
-		}
+		writeError(w, sr.logger, "Repo Delete sub", err, slog.Int("id", id))
 		return
 	}
 
@@ -249,62 +461,159 @@ func (sr *SubscriptionsRoutes) DeleteSubscription(w http.ResponseWriter, r *http
 
 	if err != nil {
 		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
 	}
 }
 
-// GetTotalStats godoc
-// @Summary Get total subscription stats
-// @Description Get total subscription price with optional filters
+// RestoreSubscription godoc
+// @Summary Restore subscription
+// @Description Restore soft-deleted subscription by ID
 // @Tags subscriptions
 // @Produce json
-// @Param user_id query string false "User ID" format(uuid) example(550e8400-e29b-41d4-a716-446655440000)
-// @Param service_name query string false "Service name"
-// @Param start_date query string false "Start date (MM-YYYY)"
-// @Param end_date query string false "End date (MM-YYYY)"
-// @Success 200 {object} types.TotalStatsResponse
-// @Failure 400 {string} string
-// @Failure 500 {string} string
-// @Router /subscriptions/total [get]
-func (sr *SubscriptionsRoutes) GetTotalStats(w http.ResponseWriter, r *http.Request) {
-	q := r.URL.Query()
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/restore [post]
+func (sr *SubscriptionsRoutes) RestoreSubscription(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, err := sr.uc.RestoreSubscription(id, actor(r))
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Restore sub", err, slog.Int("id", id))
+		return
+	}
 
-	userID := q.Get("user_id")
-	serviceName := q.Get("service_name")
-	startDateQuery := q.Get("start_date")
-	endDateQuery := q.Get("end_date")
+	w.Header().Set("ETag", etag(sub.Version))
 
-	var startDatePtr, endDatePtr *time.Time
+	err = responses.SetJsonBody(w, sub)
 
-	if len(startDateQuery) != 0 {
-		startDate, err := time.Parse("01-2006", startDateQuery)
-		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-		startDatePtr = &startDate
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
 	}
+}
 
-	if len(endDateQuery) != 0 {
-		endDate, err := time.Parse("01-2006", endDateQuery)
-		if err != nil {
-			http.Error(w, http.StatusText(http.StatusBadRequest), http.StatusBadRequest)
-			return
-		}
-		endDatePtr = &endDate
+// GetSubscriptionHistory godoc
+// @Summary Get subscription history
+// @Description Get audit log of subscription changes with snapshots before and after each change, oldest first
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} types.SubscriptionEvent
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/history [get]
+func (sr *SubscriptionsRoutes) GetSubscriptionHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	events, err := sr.uc.GetSubscriptionHistory(id)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get sub history", err, slog.Int("id", id))
+		return
 	}
 
-	total, err := sr.uc.GetTotalStats(
-		serviceName,
-		userID,
-		startDatePtr,
-		endDatePtr,
-	)
+	err = responses.SetJsonBody(w, events)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", events), slog.Any("err", err))
+	}
+}
+
+// AddPriceChange godoc
+// @Summary Change subscription price
+// @Description Set subscription price effective from a month. Totals charge every month at the price in effect then,
+// @Description so earlier months keep their previous price.
+// @Tags subscriptions
+// @Accept json
+// @Produce json
+// @Param X-Actor header string false "Who makes the change, recorded in the subscription history"
+// @Param id path int true "Subscription ID"
+// @Param If-Match header string false "Single strong ETag of the subscription version being changed, a weak one never matches"
+// @Param request body types.PriceChangeRequest true "New price"
+// @Success 200 {object} types.SubscriptionResponse
+// @Header 200 {string} ETag "New subscription version"
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 412 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/price-changes [post]
+func (sr *SubscriptionsRoutes) AddPriceChange(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	expectedVersion, err := parseIfMatch(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	var change types.PriceChangeRequest
+
+	if err := decodeJSON(r, &change); err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sub, err := sr.uc.AddPriceChange(id, change, expectedVersion, actor(r))
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Add price change", err, slog.Int("id", id), slog.Any("obj", change))
+		return
+	}
+
+	w.Header().Set("ETag", etag(sub.Version))
+
+	err = responses.SetJsonBody(w, sub)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", sub), slog.Any("err", err))
+	}
+}
+
+// GetPriceHistory godoc
+// @Summary Get subscription price history
+// @Description Get subscription price periods ordered by the month they are effective from
+// @Tags subscriptions
+// @Produce json
+// @Param id path int true "Subscription ID"
+// @Success 200 {array} types.PricePeriod
+// @Failure 400 {object} responses.Problem
+// @Failure 404 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /subscriptions/{id}/price-changes [get]
+func (sr *SubscriptionsRoutes) GetPriceHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := parseID(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	periods, err := sr.uc.GetPriceHistory(id)
+
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get price history", err, slog.Int("id", id))
+		return
+	}
 
-	err = responses.SetJsonBody(w, total)
+	err = responses.SetJsonBody(w, periods)
 
 	if err != nil {
-		sr.logger.Error("Json set body", slog.Any("obj", total), slog.Any("err", err))
-		http.Error(w, http.StatusText(http.StatusInternalServerError), http.StatusInternalServerError)
+		sr.logger.Error("Json set body", slog.Any("obj", periods), slog.Any("err", err))
 	}
 }
@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"log/slog"
+	"net/http"
+
+	"subscriptions-api/internal/responses"
+	"subscriptions-api/internal/types"
+)
+
+// GetUserSubscriptions godoc
+// @Summary Get user subscriptions
+// @Description Get subscriptions of the user. Takes the same parameters as the subscriptions list.
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID" format(uuid)
+// @Param page query int false "Page number"
+// @Param cursor query string false "Opaque cursor from next_cursor of the previous page"
+// @Param count query int true "Items per page, at most 1000"
+// @Param service_name query string false "Exact service name"
+// @Param service_name_prefix query string false "Case-insensitive service name prefix"
+// @Param sort query string false "Comma separated sort fields, minus for descending order" example(-start_date)
+// @Param include_deleted query bool false "Include soft-deleted subscriptions"
+// @Success 200 {object} types.SubscriptionsPageResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /users/{user_id}/subscriptions [get]
+func (sr *SubscriptionsRoutes) GetUserSubscriptions(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserIDPath(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	sr.listSubscriptions(w, r, func(filter *types.SubscriptionsFilter) {
+		filter.UserID = userID
+	})
+}
+
+// GetUserTotal godoc
+// @Summary Get user total
+// @Description Get total cost of the user subscriptions over a period, computed the same way as /subscriptions/total,
+// @Description with the spend and the number of active subscriptions in the current month. The monthly spend
+// @Description normalizes every billing period to a month.
+// @Tags users
+// @Produce json
+// @Param user_id path string true "User ID" format(uuid)
+// @Param service_name query string false "Service name"
+// @Param start_date query string false "Start date (MM-YYYY)"
+// @Param end_date query string false "End date (MM-YYYY)"
+// @Param currency query string false "ISO 4217 currency the amounts are converted to, RUB by default" example(USD)
+// @Success 200 {object} types.UserTotalResponse
+// @Failure 400 {object} responses.Problem
+// @Failure 500 {object} responses.Problem
+// @Router /users/{user_id}/total [get]
+func (sr *SubscriptionsRoutes) GetUserTotal(w http.ResponseWriter, r *http.Request) {
+	userID, err := parseUserIDPath(r)
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	filter, err := parseStatsFilter(r.URL.Query())
+	if err != nil {
+		responses.SetError(w, err)
+		return
+	}
+
+	total, err := sr.uc.GetUserTotal(userID, filter)
+	if err != nil {
+		writeError(w, sr.logger, "Repo Get user total", err, slog.String("user_id", userID), slog.Any("filter", filter))
+		return
+	}
+
+	err = responses.SetJsonBody(w, total)
+
+	if err != nil {
+		sr.logger.Error("Json set body", slog.Any("obj", total), slog.Any("err", err))
+	}
+}
@@ -0,0 +1,348 @@
+package repositories
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
+)
+
+// errBatchItemsFailed rolls back a batch whose items failed; the item errors
+// are returned separately.
+var errBatchItemsFailed = errors.New("batch items failed")
+
+// itemRejectedError is a database error caused by the data of one batch item:
+// a broken constraint or a value the database cannot store.
+type itemRejectedError struct {
+	index int
+	err   *pgconn.PgError
+}
+
+func (e *itemRejectedError) Error() string {
+	return fmt.Sprintf("batch item %d: %v", e.index, e.err)
+}
+
+// itemRejected wraps err as caused by the item at index when the database
+// rejected the data of the item, and returns any other error as is.
+func itemRejected(index int, err error) error {
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) && (strings.HasPrefix(pgErr.Code, "22") || strings.HasPrefix(pgErr.Code, "23")) {
+		return &itemRejectedError{index: index, err: pgErr}
+	}
+	return err
+}
+
+// rejectedItemErrors returns the per item errors of a batch of n items when
+// err is an item rejected by the database, reported as an application error.
+func rejectedItemErrors(err error, n int) ([]error, bool) {
+	var rejected *itemRejectedError
+	if !errors.As(err, &rejected) {
+		return nil, false
+	}
+
+	errs := make([]error, n)
+
+	switch {
+	case rejected.err.Code == "23503" && rejected.err.ConstraintName == "subscriptions_serviceid_fkey":
+		errs[rejected.index] = apperrors.ServiceNotFound
+	case strings.HasPrefix(rejected.err.Code, "23"):
+		errs[rejected.index] = apperrors.ConstraintViolated(rejected.err.ConstraintName)
+	default:
+		errs[rejected.index] = apperrors.ValueRejected(rejected.err.Message)
+	}
+
+	return errs, true
+}
+
+// inPgxTx runs fn in a transaction on the underlying pgx connection, so that
+// queries can be pipelined with pgx batches.
+func (sr SubscriptionsPostgresRepository) inPgxTx(fn func(ctx context.Context, tx pgx.Tx) error) error {
+	ctx := context.Background()
+
+	conn, err := sr.db.Conn(ctx)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	return conn.Raw(func(driverConn any) error {
+		return pgx.BeginFunc(ctx, driverConn.(*stdlib.Conn).Conn(), func(tx pgx.Tx) error {
+			return fn(ctx, tx)
+		})
+	})
+}
+
+// queryBatch sends the queued queries and scans the subscription each of them returns.
+func queryBatch(ctx context.Context, tx pgx.Tx, batch *pgx.Batch) ([]types.SubscriptionResponse, error) {
+	br := tx.SendBatch(ctx, batch)
+	defer br.Close()
+
+	res := make([]types.SubscriptionResponse, 0, batch.Len())
+
+	for i := range batch.Len() {
+		sub, err := scanSubscription(br.QueryRow())
+		if err != nil {
+			return nil, itemRejected(i, err)
+		}
+		res = append(res, sub)
+	}
+
+	return res, br.Close()
+}
+
+func queueEvent(batch *pgx.Batch, action, actor string, before, after *types.SubscriptionResponse) error {
+	args, err := insertEventArgs(action, actor, before, after)
+	if err != nil {
+		return err
+	}
+
+	batch.Queue(insertEventQuery, args...)
+	return nil
+}
+
+// pgxQueryRow adapts tx to ensureService.
+func pgxQueryRow(ctx context.Context, tx pgx.Tx) func(query string, args ...any) rowScanner {
+	return func(query string, args ...any) rowScanner {
+		return tx.QueryRow(ctx, query, args...)
+	}
+}
+
+// ensureServices resolves the services of subs resolved by name only, creating
+// the unknown ones in the transaction of the batch.
+func ensureServices(ctx context.Context, tx pgx.Tx, subs []*types.SubscriptionRequest) error {
+	for i, sub := range subs {
+		if err := ensureService(pgxQueryRow(ctx, tx), sub); err != nil {
+			return itemRejected(i, err)
+		}
+	}
+	return nil
+}
+
+// lockSubscriptions reads live subscriptions for update and returns them by ID.
+func lockSubscriptions(ctx context.Context, tx pgx.Tx, ids []int) (map[int]types.SubscriptionResponse, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE ID = ANY($1) AND DeletedAt IS NULL
+		ORDER BY ID
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(ctx, query, ids)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	res := make(map[int]types.SubscriptionResponse, len(ids))
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+		res[sub.ID] = sub
+	}
+
+	return res, rows.Err()
+}
+
+// checkLocked reports per item errors for subscriptions that are missing or
+// whose version differs from the expected one.
+func checkLocked(locked map[int]types.SubscriptionResponse, ids []int, versions []*int) ([]error, bool) {
+	errs := make([]error, len(ids))
+	failed := false
+
+	for i, id := range ids {
+		sub, ok := locked[id]
+
+		switch {
+		case !ok:
+			errs[i] = apperrors.SubscriptionNotFound
+		case versions[i] != nil && sub.Version != *versions[i]:
+			errs[i] = apperrors.VersionMismatch
+		default:
+			continue
+		}
+
+		failed = true
+	}
+
+	return errs, failed
+}
+
+// SaveSubscriptions creates all subscriptions, and the services of unknown
+// names, in one transaction. When the database rejects an item, nothing is
+// created and the error of that item is returned.
+func (sr SubscriptionsPostgresRepository) SaveSubscriptions(subs []types.SubscriptionRequest, actor string) ([]types.SubscriptionResponse, []error, error) {
+	var res []types.SubscriptionResponse
+
+	err := sr.inPgxTx(func(ctx context.Context, tx pgx.Tx) error {
+		pending := make([]*types.SubscriptionRequest, len(subs))
+		for i := range subs {
+			pending[i] = &subs[i]
+		}
+
+		if err := ensureServices(ctx, tx, pending); err != nil {
+			return err
+		}
+
+		batch := &pgx.Batch{}
+		for _, sub := range subs {
+			batch.Queue(insertSubscriptionQuery, insertSubscriptionArgs(sub)...)
+		}
+
+		var err error
+		if res, err = queryBatch(ctx, tx, batch); err != nil {
+			return err
+		}
+
+		batch = &pgx.Batch{}
+		for i := range res {
+			batch.Queue(insertInitialPriceQuery, insertInitialPriceArgs(res[i])...)
+			if err := queueEvent(batch, types.EventCreated, actor, nil, &res[i]); err != nil {
+				return err
+			}
+		}
+
+		return tx.SendBatch(ctx, batch).Close()
+	})
+
+	if errs, ok := rejectedItemErrors(err, len(subs)); ok {
+		return nil, errs, nil
+	}
+
+	return res, nil, err
+}
+
+// UpdateSubscriptions replaces all subscriptions in one transaction. When any
+// item fails, nothing is changed and the per item errors are returned; of the
+// items the database rejects, the first one is reported.
+func (sr SubscriptionsPostgresRepository) UpdateSubscriptions(items []types.SubscriptionUpdateItem, actor string) ([]types.SubscriptionResponse, []error, error) {
+	ids := make([]int, len(items))
+	versions := make([]*int, len(items))
+	for i, item := range items {
+		ids[i] = item.ID
+		versions[i] = item.Version
+	}
+
+	var res []types.SubscriptionResponse
+	var errs []error
+
+	err := sr.inPgxTx(func(ctx context.Context, tx pgx.Tx) error {
+		locked, err := lockSubscriptions(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		var failed bool
+		if errs, failed = checkLocked(locked, ids, versions); failed {
+			return errBatchItemsFailed
+		}
+
+		for i, item := range items {
+			if errs[i] = checkDates(locked[item.ID], item.StartDate, item.EndDate); errs[i] != nil {
+				failed = true
+			}
+		}
+
+		if failed {
+			return errBatchItemsFailed
+		}
+
+		pending := make([]*types.SubscriptionRequest, len(items))
+		for i := range items {
+			pending[i] = &items[i].SubscriptionRequest
+		}
+
+		if err := ensureServices(ctx, tx, pending); err != nil {
+			return err
+		}
+
+		batch := &pgx.Batch{}
+		for _, item := range items {
+			batch.Queue(updateSubscriptionQuery, updateSubscriptionArgs(item.ID, item.SubscriptionRequest)...)
+		}
+
+		if res, err = queryBatch(ctx, tx, batch); err != nil {
+			return err
+		}
+
+		batch = &pgx.Batch{}
+		for i := range res {
+			before := locked[res[i].ID]
+			for _, stmt := range pricePeriodUpdates(before, res[i]) {
+				batch.Queue(stmt.query, stmt.args...)
+			}
+			if err := queueEvent(batch, types.EventUpdated, actor, &before, &res[i]); err != nil {
+				return err
+			}
+		}
+
+		return tx.SendBatch(ctx, batch).Close()
+	})
+
+	if errors.Is(err, errBatchItemsFailed) {
+		return nil, errs, nil
+	}
+
+	if errs, ok := rejectedItemErrors(err, len(items)); ok {
+		return nil, errs, nil
+	}
+
+	return res, nil, err
+}
+
+// DeleteSubscriptions soft-deletes all subscriptions in one transaction. When
+// any of them is missing, nothing is deleted and the per item errors are returned.
+func (sr SubscriptionsPostgresRepository) DeleteSubscriptions(ids []int, actor string) ([]types.SubscriptionResponse, []error, error) {
+	var res []types.SubscriptionResponse
+	var errs []error
+
+	err := sr.inPgxTx(func(ctx context.Context, tx pgx.Tx) error {
+		locked, err := lockSubscriptions(ctx, tx, ids)
+		if err != nil {
+			return err
+		}
+
+		var failed bool
+		if errs, failed = checkLocked(locked, ids, make([]*int, len(ids))); failed {
+			return errBatchItemsFailed
+		}
+
+		batch := &pgx.Batch{}
+		for _, id := range ids {
+			batch.Queue(deleteSubscriptionQuery, id)
+		}
+
+		if res, err = queryBatch(ctx, tx, batch); err != nil {
+			return err
+		}
+
+		batch = &pgx.Batch{}
+		for i := range res {
+			before := locked[res[i].ID]
+			if err := queueEvent(batch, types.EventDeleted, actor, &before, &res[i]); err != nil {
+				return err
+			}
+		}
+
+		return tx.SendBatch(ctx, batch).Close()
+	})
+
+	if errors.Is(err, errBatchItemsFailed) {
+		return nil, errs, nil
+	}
+
+	if errs, ok := rejectedItemErrors(err, len(ids)); ok {
+		return nil, errs, nil
+	}
+
+	return res, nil, err
+}
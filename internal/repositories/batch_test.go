@@ -0,0 +1,63 @@
+package repositories
+
+import (
+	"errors"
+	"fmt"
+	"subscriptions-api/internal/apperrors"
+	"testing"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+func TestRejectedItemErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		err     error
+		wantErr error
+	}{
+		{
+			name:    "unknown service",
+			err:     &pgconn.PgError{Code: "23503", ConstraintName: "subscriptions_serviceid_fkey"},
+			wantErr: apperrors.ServiceNotFound,
+		},
+		{
+			name:    "check violation",
+			err:     &pgconn.PgError{Code: "23514", ConstraintName: "subscriptions_end_after_start"},
+			wantErr: apperrors.ConstraintViolated("subscriptions_end_after_start"),
+		},
+		{
+			name:    "wrapped unique violation",
+			err:     fmt.Errorf("insert: %w", &pgconn.PgError{Code: "23505", ConstraintName: "services_name_idx"}),
+			wantErr: apperrors.ConstraintViolated("services_name_idx"),
+		},
+		{
+			name:    "value out of range",
+			err:     &pgconn.PgError{Code: "22003", Message: "integer out of range"},
+			wantErr: apperrors.ValueRejected("integer out of range"),
+		},
+		{name: "serialization failure", err: &pgconn.PgError{Code: "40001"}},
+		{name: "not a database error", err: errors.New("connection reset")},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := itemRejected(2, tt.err)
+			errs, ok := rejectedItemErrors(err, 3)
+
+			if tt.wantErr == nil {
+				if ok || err != tt.err {
+					t.Fatalf("itemRejected(%v) = %v with item errors %v, want the error unchanged", tt.err, err, errs)
+				}
+				return
+			}
+
+			if !ok || len(errs) != 3 || errs[0] != nil || errs[1] != nil {
+				t.Fatalf("rejectedItemErrors = %v, %v, want only the error of item 2", errs, ok)
+			}
+
+			if !errors.Is(errs[2], tt.wantErr) || errs[2].Error() != tt.wantErr.Error() {
+				t.Errorf("item error = %v, want %v", errs[2], tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+const discountColumns = "ID, Type, Value, StartMonth, Months"
+
+func scanDiscount(r rowScanner) (types.Discount, error) {
+	var d types.Discount
+	err := r.Scan(&d.ID, &d.Type, &d.Value, &d.StartMonth, &d.Months)
+	return d, err
+}
+
+// bumpVersionQuery marks the subscription changed by a change of its discounts.
+const bumpVersionQuery = `
+	UPDATE subscriptions
+	SET Version = Version + 1
+	WHERE ID = $1
+	RETURNING ` + subscriptionColumns
+
+func isExclusionViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23P01"
+}
+
+// AddDiscount schedules a discount, failing when it overlaps another discount
+// of the subscription. StartMonth must be set.
+func (sr SubscriptionsPostgresRepository) AddDiscount(id int, req types.DiscountRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, types.Discount, error) {
+	query := `
+		INSERT INTO subscription_discounts (SubscriptionID, Type, Value, StartMonth, Months)
+		VALUES ($1, $2, $3, $4, $5)
+		RETURNING ` + discountColumns
+
+	var discount types.Discount
+
+	sub, err := sr.change(id, types.EventDiscountAdded, actor, false, expectedVersion, func(tx *sql.Tx, _ types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		var err error
+
+		discount, err = scanDiscount(tx.QueryRow(query, id, req.Type, req.Value, time.Time(*req.StartMonth), req.Months))
+		if isExclusionViolation(err) {
+			return types.SubscriptionResponse{}, apperrors.DiscountOverlap
+		}
+		if err != nil {
+			return types.SubscriptionResponse{}, err
+		}
+
+		return scanSubscription(tx.QueryRow(bumpVersionQuery, id))
+	})
+
+	return sub, discount, err
+}
+
+func (sr SubscriptionsPostgresRepository) GetDiscounts(id int) ([]types.Discount, error) {
+	if _, err := sr.GetSubscription(id); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT ` + discountColumns + `
+		FROM subscription_discounts
+		WHERE SubscriptionID = $1
+		ORDER BY StartMonth
+	`
+
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.Discount, 0)
+
+	for rows.Next() {
+		discount, err := scanDiscount(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, discount)
+	}
+
+	return result, rows.Err()
+}
+
+func (sr SubscriptionsPostgresRepository) DeleteDiscount(id, discountID int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	query := `
+		DELETE FROM subscription_discounts
+		WHERE ID = $1 AND SubscriptionID = $2
+		RETURNING ID
+	`
+
+	return sr.change(id, types.EventDiscountRemoved, actor, false, expectedVersion, func(tx *sql.Tx, _ types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		err := tx.QueryRow(query, discountID, id).Scan(&discountID)
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.SubscriptionResponse{}, apperrors.DiscountNotFound
+		}
+		if err != nil {
+			return types.SubscriptionResponse{}, err
+		}
+
+		return scanSubscription(tx.QueryRow(bumpVersionQuery, id))
+	})
+}
@@ -0,0 +1,159 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+)
+
+func (sr SubscriptionsPostgresRepository) inTx(fn func(tx *sql.Tx) error) error {
+	return inTx(sr.db, fn)
+}
+
+func inTx(db *sql.DB, fn func(tx *sql.Tx) error) error {
+	tx, err := db.Begin()
+	if err != nil {
+		return err
+	}
+
+	if err := fn(tx); err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// lockSubscription reads a subscription for update. deleted selects whether a
+// live or a soft-deleted subscription is expected.
+func lockSubscription(tx *sql.Tx, id int, deleted bool) (types.SubscriptionResponse, error) {
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE ID = $1 AND (DeletedAt IS NOT NULL) = $2
+		FOR UPDATE
+	`
+
+	res, err := scanSubscription(tx.QueryRow(query, id, deleted))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return types.SubscriptionResponse{}, apperrors.SubscriptionNotFound
+		}
+		return types.SubscriptionResponse{}, err
+	}
+
+	return res, nil
+}
+
+// change applies fn to a locked subscription in a transaction, checking the
+// expected version first, and records the before and after snapshots.
+func (sr SubscriptionsPostgresRepository) change(
+	id int,
+	action, actor string,
+	deleted bool,
+	expectedVersion *int,
+	fn func(tx *sql.Tx, before types.SubscriptionResponse) (types.SubscriptionResponse, error),
+) (types.SubscriptionResponse, error) {
+	var res types.SubscriptionResponse
+
+	err := sr.inTx(func(tx *sql.Tx) error {
+		before, err := lockSubscription(tx, id, deleted)
+		if err != nil {
+			return err
+		}
+
+		if expectedVersion != nil && before.Version != *expectedVersion {
+			return apperrors.VersionMismatch
+		}
+
+		if res, err = fn(tx, before); err != nil {
+			return err
+		}
+
+		return insertEvent(tx, action, actor, &before, &res)
+	})
+
+	return res, err
+}
+
+func snapshot(sub *types.SubscriptionResponse) (any, error) {
+	if sub == nil {
+		return nil, nil
+	}
+	return json.Marshal(sub)
+}
+
+const insertEventQuery = `
+	INSERT INTO subscription_events
+	(SubscriptionID, Action, Actor, OldData, NewData)
+	VALUES ($1, $2, NULLIF($3, ''), $4, $5)
+`
+
+func insertEventArgs(action, actor string, before, after *types.SubscriptionResponse) ([]any, error) {
+	oldData, err := snapshot(before)
+	if err != nil {
+		return nil, err
+	}
+
+	newData, err := snapshot(after)
+	if err != nil {
+		return nil, err
+	}
+
+	return []any{after.ID, action, actor, oldData, newData}, nil
+}
+
+func insertEvent(tx *sql.Tx, action, actor string, before, after *types.SubscriptionResponse) error {
+	args, err := insertEventArgs(action, actor, before, after)
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec(insertEventQuery, args...)
+	return err
+}
+
+func (sr SubscriptionsPostgresRepository) GetSubscriptionHistory(id int) ([]types.SubscriptionEvent, error) {
+	var exists bool
+	if err := sr.db.QueryRow(`SELECT EXISTS (SELECT 1 FROM subscriptions WHERE ID = $1)`, id).Scan(&exists); err != nil {
+		return nil, err
+	}
+
+	if !exists {
+		return nil, apperrors.SubscriptionNotFound
+	}
+
+	query := `
+		SELECT ID, SubscriptionID, Action, COALESCE(Actor, ''), OldData, NewData, CreatedAt
+		FROM subscription_events
+		WHERE SubscriptionID = $1
+		ORDER BY ID
+	`
+
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.SubscriptionEvent, 0)
+
+	for rows.Next() {
+		var event types.SubscriptionEvent
+		var oldData, newData []byte
+
+		err := rows.Scan(&event.ID, &event.SubscriptionID, &event.Action, &event.Actor, &oldData, &newData, &event.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+
+		event.Before = json.RawMessage(oldData)
+		event.After = json.RawMessage(newData)
+
+		result = append(result, event)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+)
+
+type ExchangeRatesRepository interface {
+	GetExchangeRates() ([]types.ExchangeRate, error)
+	GetExchangeRate(currency string) (types.ExchangeRate, error)
+	SetExchangeRate(currency string, rate float64) (types.ExchangeRate, error)
+	DeleteExchangeRate(currency string) error
+}
+
+type ExchangeRatesPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewExchangeRatesPostgresRepository(db *sql.DB) ExchangeRatesPostgresRepository {
+	return ExchangeRatesPostgresRepository{db}
+}
+
+func scanExchangeRate(r rowScanner) (types.ExchangeRate, error) {
+	var rate types.ExchangeRate
+
+	if err := r.Scan(&rate.Currency, &rate.Rate, &rate.UpdatedAt); err != nil {
+		return types.ExchangeRate{}, err
+	}
+
+	return rate, nil
+}
+
+func (er ExchangeRatesPostgresRepository) GetExchangeRates() ([]types.ExchangeRate, error) {
+	rows, err := er.db.Query(`SELECT Currency, Rate, UpdatedAt FROM exchange_rates ORDER BY Currency`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.ExchangeRate, 0)
+
+	for rows.Next() {
+		rate, err := scanExchangeRate(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, rate)
+	}
+
+	return result, rows.Err()
+}
+
+func (er ExchangeRatesPostgresRepository) GetExchangeRate(currency string) (types.ExchangeRate, error) {
+	query := `SELECT Currency, Rate, UpdatedAt FROM exchange_rates WHERE Currency = $1`
+
+	rate, err := scanExchangeRate(er.db.QueryRow(query, currency))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.ExchangeRate{}, apperrors.ExchangeRateNotFound
+	}
+
+	return rate, err
+}
+
+func (er ExchangeRatesPostgresRepository) SetExchangeRate(currency string, rate float64) (types.ExchangeRate, error) {
+	query := `
+		INSERT INTO exchange_rates (Currency, Rate)
+		VALUES ($1, $2)
+		ON CONFLICT (Currency) DO UPDATE SET Rate = EXCLUDED.Rate, UpdatedAt = now()
+		RETURNING Currency, Rate, UpdatedAt
+	`
+
+	return scanExchangeRate(er.db.QueryRow(query, currency, rate))
+}
+
+func (er ExchangeRatesPostgresRepository) DeleteExchangeRate(currency string) error {
+	res, err := er.db.Exec(`DELETE FROM exchange_rates WHERE Currency = $1`, currency)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		return apperrors.ExchangeRateNotFound
+	}
+
+	return nil
+}
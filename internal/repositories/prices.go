@@ -0,0 +1,186 @@
+package repositories
+
+import (
+	"database/sql"
+	"subscriptions-api/internal/types"
+	"time"
+)
+
+const insertInitialPriceQuery = `
+	INSERT INTO subscription_prices (SubscriptionID, EffectiveFrom, Price, Currency, BillingPeriod, BillingAnchor)
+	VALUES ($1, $2, $3, $4, $5, $6)
+`
+
+func insertInitialPriceArgs(sub types.SubscriptionResponse) []any {
+	return []any{sub.ID, sub.StartDate, sub.Price, sub.Currency, sub.BillingPeriod, nullableTime(sub.BillingAnchor)}
+}
+
+func recordInitialPrice(tx *sql.Tx, sub types.SubscriptionResponse) error {
+	_, err := tx.Exec(insertInitialPriceQuery, insertInitialPriceArgs(sub)...)
+	return err
+}
+
+// statement is a query with its arguments, executed in a transaction or
+// queued in a pgx batch.
+type statement struct {
+	query string
+	args  []any
+}
+
+// reanchorPricesQueries move the first price period to the subscription start:
+// the periods superseded by the one in effect at the start are dropped and the
+// earliest remaining period starts with the subscription, so every month is
+// charged at a recorded price.
+var reanchorPricesQueries = []string{`
+	DELETE FROM subscription_prices p
+	USING subscriptions s
+	WHERE s.ID = $1 AND p.SubscriptionID = s.ID AND p.EffectiveFrom < (
+		SELECT MAX(EffectiveFrom) FROM subscription_prices
+		WHERE SubscriptionID = s.ID AND EffectiveFrom <= s.StartDate
+	)
+`, `
+	UPDATE subscription_prices p
+	SET EffectiveFrom = s.StartDate
+	FROM subscriptions s
+	WHERE s.ID = $1 AND p.SubscriptionID = s.ID AND p.EffectiveFrom = (
+		SELECT MIN(EffectiveFrom) FROM subscription_prices WHERE SubscriptionID = s.ID
+	)
+`}
+
+// recordCurrentPriceQueries make the subscription price and billing terms
+// effective from the current month, or from its start if it has not started yet, replacing the
+// price changes scheduled after it and leaving earlier months charged at the
+// prices that were in effect then.
+var recordCurrentPriceQueries = []string{`
+	DELETE FROM subscription_prices p
+	USING subscriptions s
+	WHERE s.ID = $1 AND p.SubscriptionID = s.ID
+		AND p.EffectiveFrom > GREATEST(s.StartDate, date_trunc('month', CURRENT_DATE)::date)
+`, `
+	INSERT INTO subscription_prices (SubscriptionID, EffectiveFrom, Price, Currency, BillingPeriod, BillingAnchor)
+	SELECT ID, GREATEST(StartDate, date_trunc('month', CURRENT_DATE)::date), Price, Currency, BillingPeriod, BillingAnchor
+	FROM subscriptions
+	WHERE ID = $1
+	ON CONFLICT (SubscriptionID, EffectiveFrom) DO UPDATE
+	SET Price = EXCLUDED.Price, Currency = EXCLUDED.Currency,
+		BillingPeriod = EXCLUDED.BillingPeriod, BillingAnchor = EXCLUDED.BillingAnchor
+`}
+
+// chargeTermsChanged reports whether an update changes what the subscription
+// is charged from now on: its price, currency or billing period and anchor.
+func chargeTermsChanged(before, after types.SubscriptionResponse) bool {
+	sameAnchor := before.BillingAnchor == nil && after.BillingAnchor == nil ||
+		before.BillingAnchor != nil && after.BillingAnchor != nil && before.BillingAnchor.Equal(*after.BillingAnchor)
+
+	return after.Price != before.Price || after.Currency != before.Currency ||
+		after.BillingPeriod != before.BillingPeriod || !sameAnchor
+}
+
+// pricePeriodUpdates returns the statements keeping the price periods of a
+// subscription in line with its update from before to after.
+func pricePeriodUpdates(before, after types.SubscriptionResponse) []statement {
+	var queries []string
+
+	if !after.StartDate.Equal(before.StartDate) {
+		queries = append(queries, reanchorPricesQueries...)
+	}
+
+	if chargeTermsChanged(before, after) {
+		queries = append(queries, recordCurrentPriceQueries...)
+	}
+
+	stmts := make([]statement, len(queries))
+	for i, query := range queries {
+		stmts[i] = statement{query: query, args: []any{after.ID}}
+	}
+
+	return stmts
+}
+
+// updatePricePeriods runs the pricePeriodUpdates of an update in tx.
+func updatePricePeriods(tx *sql.Tx, before, after types.SubscriptionResponse) error {
+	for _, stmt := range pricePeriodUpdates(before, after) {
+		if _, err := tx.Exec(stmt.query, stmt.args...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AddPriceChange records a price effective from the given month and
+// refreshes the subscription price to the one in effect this month.
+func (sr SubscriptionsPostgresRepository) AddPriceChange(id int, change types.PriceChangeRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	// The new price keeps the currency and billing terms of the period it starts in.
+	insertQuery := `
+		INSERT INTO subscription_prices (SubscriptionID, EffectiveFrom, Price, Currency, BillingPeriod, BillingAnchor)
+		SELECT s.ID, $2, $3, COALESCE(p.Currency, s.Currency), COALESCE(p.BillingPeriod, s.BillingPeriod),
+			CASE WHEN p.Currency IS NULL THEN s.BillingAnchor ELSE p.BillingAnchor END
+		FROM subscriptions s
+		LEFT JOIN LATERAL (
+			SELECT Currency, BillingPeriod, BillingAnchor FROM subscription_prices
+			WHERE SubscriptionID = s.ID AND EffectiveFrom <= $2
+			ORDER BY EffectiveFrom DESC
+			LIMIT 1
+		) AS p ON true
+		WHERE s.ID = $1
+		ON CONFLICT (SubscriptionID, EffectiveFrom) DO UPDATE SET Price = EXCLUDED.Price
+	`
+
+	updateQuery := `
+		UPDATE subscriptions
+		SET Price = COALESCE((
+				SELECT p.Price FROM subscription_prices p
+				WHERE p.SubscriptionID = subscriptions.ID AND p.EffectiveFrom <= date_trunc('month', CURRENT_DATE)
+				ORDER BY p.EffectiveFrom DESC
+				LIMIT 1
+			), Price),
+			Version = Version + 1
+		WHERE ID = $1
+		RETURNING ` + subscriptionColumns
+
+	return sr.change(id, types.EventPriceChanged, actor, false, expectedVersion, func(tx *sql.Tx, _ types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		if _, err := tx.Exec(insertQuery, id, time.Time(change.EffectiveFrom), change.Price); err != nil {
+			return types.SubscriptionResponse{}, err
+		}
+
+		return scanSubscription(tx.QueryRow(updateQuery, id))
+	})
+}
+
+func (sr SubscriptionsPostgresRepository) GetPriceHistory(id int) ([]types.PricePeriod, error) {
+	if _, err := sr.GetSubscription(id); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT EffectiveFrom, Price, Currency, BillingPeriod, BillingAnchor
+		FROM subscription_prices
+		WHERE SubscriptionID = $1
+		ORDER BY EffectiveFrom
+	`
+
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.PricePeriod, 0)
+
+	for rows.Next() {
+		var period types.PricePeriod
+		var billingAnchor sql.NullTime
+
+		if err := rows.Scan(&period.EffectiveFrom, &period.Price, &period.Currency, &period.BillingPeriod, &billingAnchor); err != nil {
+			return nil, err
+		}
+
+		if billingAnchor.Valid {
+			period.BillingAnchor = &billingAnchor.Time
+		}
+
+		result = append(result, period)
+	}
+
+	return result, rows.Err()
+}
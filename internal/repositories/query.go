@@ -0,0 +1,150 @@
+package repositories
+
+import (
+	"fmt"
+	"strings"
+	"subscriptions-api/internal/types"
+)
+
+// queryBuilder accumulates WHERE conditions together with their positional arguments.
+type queryBuilder struct {
+	conds []string
+	args  []any
+}
+
+// arg registers v as the next positional argument and returns its placeholder.
+func (qb *queryBuilder) arg(v any) string {
+	qb.args = append(qb.args, v)
+	return fmt.Sprintf("$%d", len(qb.args))
+}
+
+// where adds a condition in which every %s is replaced by a placeholder for the matching value.
+func (qb *queryBuilder) where(cond string, values ...any) {
+	placeholders := make([]any, len(values))
+	for i, v := range values {
+		placeholders[i] = qb.arg(v)
+	}
+
+	qb.conds = append(qb.conds, fmt.Sprintf(cond, placeholders...))
+}
+
+func (qb *queryBuilder) whereSQL() string {
+	if len(qb.conds) == 0 {
+		return ""
+	}
+	return " WHERE " + strings.Join(qb.conds, " AND ")
+}
+
+func (qb *queryBuilder) filterSubscriptions(filter types.SubscriptionsFilter) {
+	switch filter.Deleted {
+	case types.ExcludeDeleted:
+		qb.where("DeletedAt IS NULL")
+	case types.OnlyDeleted:
+		qb.where("DeletedAt IS NOT NULL")
+	}
+
+	if len(filter.UserID) != 0 {
+		qb.where("UserID = %s", filter.UserID)
+	}
+
+	if len(filter.ServiceName) != 0 {
+		qb.where(serviceMatchCond, filter.ServiceName)
+	}
+
+	if len(filter.ServiceNamePrefix) != 0 {
+		qb.where(`ServiceName ILIKE %s ESCAPE '\'`, escapeLike(filter.ServiceNamePrefix)+"%")
+	}
+
+	if filter.PriceMin != nil {
+		qb.where("Price >= %s", *filter.PriceMin)
+	}
+
+	if filter.PriceMax != nil {
+		qb.where("Price <= %s", *filter.PriceMax)
+	}
+
+	if filter.StartFrom != nil {
+		qb.where("StartDate >= %s", *filter.StartFrom)
+	}
+
+	if filter.StartTo != nil {
+		qb.where("StartDate <= %s", *filter.StartTo)
+	}
+
+	if filter.ActiveAt != nil {
+		// Paused months are not charged, so they do not count as active, as in the stats.
+		qb.where(`StartDate <= %[1]s AND (EndDate IS NULL OR EndDate >= %[1]s) AND NOT EXISTS (
+			SELECT 1 FROM subscription_pauses sp
+			WHERE sp.SubscriptionID = subscriptions.ID AND sp.StartMonth <= %[1]s AND (sp.EndMonth IS NULL OR %[1]s < sp.EndMonth)
+		)`, *filter.ActiveAt)
+	}
+
+	if len(filter.Status) != 0 {
+		qb.where("Status = %s", filter.Status)
+	}
+}
+
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(s string) string {
+	return likeEscaper.Replace(s)
+}
+
+// sortColumns maps sortable fields to their SQL expression and the type cursor values are cast to.
+// EndDate is coalesced so that open-ended subscriptions sort last and keyset comparisons never see NULL.
+var sortColumns = map[string]struct{ expr, cast string }{
+	types.SortByID:          {"ID", "int"},
+	types.SortByServiceName: {"ServiceName", "text"},
+	types.SortByPrice:       {"Price", "int"},
+	types.SortByUserID:      {"UserID", "uuid"},
+	types.SortByStartDate:   {"StartDate", "date"},
+	types.SortByEndDate:     {"COALESCE(EndDate, 'infinity'::date)", "date"},
+}
+
+func direction(desc bool) string {
+	if desc {
+		return "DESC"
+	}
+	return "ASC"
+}
+
+// orderBySQL renders the requested sort with ID as the final tie-breaker,
+// so that pages are stable for both offset and keyset pagination.
+func orderBySQL(sort []types.SortField) string {
+	parts := make([]string, 0, len(sort)+1)
+	for _, f := range sort {
+		parts = append(parts, sortColumns[f.Field].expr+" "+direction(f.Desc))
+	}
+	parts = append(parts, "ID ASC")
+
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// after adds the keyset condition selecting rows that follow cursor in the given sort order:
+// (a > $1) OR (a = $1 AND b < $2) OR (a = $1 AND b = $2 AND ID > $3) for sort=a,-b.
+func (qb *queryBuilder) after(sort []types.SortField, cursor types.Cursor) {
+	type key struct{ expr, op, placeholder string }
+
+	keys := make([]key, 0, len(sort)+1)
+	for i, f := range sort {
+		col := sortColumns[f.Field]
+		op := ">"
+		if f.Desc {
+			op = "<"
+		}
+		keys = append(keys, key{col.expr, op, qb.arg(cursor.Values[i]) + "::" + col.cast})
+	}
+	keys = append(keys, key{"ID", ">", qb.arg(cursor.ID)})
+
+	disjuncts := make([]string, 0, len(keys))
+	for i, k := range keys {
+		conj := make([]string, 0, i+1)
+		for _, prev := range keys[:i] {
+			conj = append(conj, prev.expr+" = "+prev.placeholder)
+		}
+		conj = append(conj, k.expr+" "+k.op+" "+k.placeholder)
+		disjuncts = append(disjuncts, "("+strings.Join(conj, " AND ")+")")
+	}
+
+	qb.conds = append(qb.conds, "("+strings.Join(disjuncts, " OR ")+")")
+}
@@ -0,0 +1,352 @@
+package repositories
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"strings"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+
+	"github.com/jackc/pgx/v5/pgconn"
+)
+
+type ServicesRepository interface {
+	GetServices() ([]types.Service, error)
+	GetService(id int) (types.Service, error)
+	FindService(name string) (types.Service, error)
+	SaveService(service types.ServiceRequest) (types.Service, error)
+	UpdateService(id int, service types.ServiceRequest, actor string) (types.Service, error)
+	DeleteService(id int) error
+}
+
+type ServicesPostgresRepository struct {
+	db *sql.DB
+}
+
+func NewServicesPostgresRepository(db *sql.DB) ServicesPostgresRepository {
+	return ServicesPostgresRepository{db}
+}
+
+// serviceColumns selects a service with its aliases aggregated into a JSON array.
+const serviceColumns = `
+	sv.ID, sv.Name, sv.DefaultPrice, sv.CreatedAt,
+	COALESCE((SELECT json_agg(a.Alias ORDER BY a.Alias) FROM service_aliases a WHERE a.ServiceID = sv.ID), '[]')
+`
+
+func scanService(r rowScanner) (types.Service, error) {
+	var service types.Service
+	var defaultPrice sql.NullInt64
+	var aliases []byte
+
+	if err := r.Scan(&service.ID, &service.Name, &defaultPrice, &service.CreatedAt, &aliases); err != nil {
+		return types.Service{}, err
+	}
+
+	if err := json.Unmarshal(aliases, &service.Aliases); err != nil {
+		return types.Service{}, err
+	}
+
+	if defaultPrice.Valid {
+		price := int(defaultPrice.Int64)
+		service.DefaultPrice = &price
+	}
+
+	return service, nil
+}
+
+func isUniqueViolation(err error) bool {
+	var pgErr *pgconn.PgError
+	return errors.As(err, &pgErr) && pgErr.Code == "23505"
+}
+
+func (sr ServicesPostgresRepository) GetServices() ([]types.Service, error) {
+	rows, err := sr.db.Query(`SELECT ` + serviceColumns + ` FROM services sv ORDER BY sv.Name`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.Service, 0)
+
+	for rows.Next() {
+		service, err := scanService(rows)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, service)
+	}
+
+	return result, rows.Err()
+}
+
+func getService(q querier, where string, args ...any) (types.Service, error) {
+	service, err := scanService(q.QueryRow(`SELECT `+serviceColumns+` FROM services sv WHERE `+where, args...))
+	if errors.Is(err, sql.ErrNoRows) {
+		return types.Service{}, apperrors.ServiceNotFound
+	}
+	return service, err
+}
+
+const serviceByIDCond = `sv.ID = $1`
+
+// serviceByNameCond matches the service named $1 or having it as an alias, ignoring case.
+const serviceByNameCond = `
+	lower(sv.Name) = lower($1)
+	OR sv.ID = (SELECT a.ServiceID FROM service_aliases a WHERE a.Alias = lower($1))
+`
+
+func (sr ServicesPostgresRepository) GetService(id int) (types.Service, error) {
+	return getService(sr.db, serviceByIDCond, id)
+}
+
+// FindService finds a service by its name or one of its aliases, ignoring case.
+func (sr ServicesPostgresRepository) FindService(name string) (types.Service, error) {
+	return getService(sr.db, serviceByNameCond, name)
+}
+
+// serviceMatchCond matches subscriptions to the service named %[1]s or having it as an alias.
+const serviceMatchCond = `ServiceID IN (
+	SELECT ID FROM services WHERE lower(Name) = lower(%[1]s)
+	UNION SELECT ServiceID FROM service_aliases WHERE Alias = lower(%[1]s)
+)`
+
+// ensureServiceQuery finds the service named $1 or having it as an alias and
+// creates it when there is none. It returns no row when the service is created
+// concurrently, a new statement then finds it.
+const ensureServiceQuery = `
+	WITH found AS (
+		SELECT ID, Name FROM services WHERE lower(Name) = lower($1)
+		UNION
+		SELECT sv.ID, sv.Name FROM services sv JOIN service_aliases a ON a.ServiceID = sv.ID WHERE a.Alias = lower($1)
+	), created AS (
+		INSERT INTO services (Name)
+		SELECT $1 WHERE NOT EXISTS (SELECT 1 FROM found)
+		ON CONFLICT DO NOTHING
+		RETURNING ID, Name
+	)
+	SELECT ID, Name FROM found
+	UNION ALL
+	SELECT ID, Name FROM created
+	LIMIT 1
+`
+
+// ensureService points a subscription resolved by name only at its service,
+// creating the service within the transaction of the subscription write so
+// that a failed write leaves no service behind.
+func ensureService(queryRow func(query string, args ...any) rowScanner, sub *types.SubscriptionRequest) error {
+	if sub.ServiceID != nil {
+		return nil
+	}
+
+	var id int
+	var name string
+
+	err := queryRow(ensureServiceQuery, sub.ServiceName).Scan(&id, &name)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = queryRow(ensureServiceQuery, sub.ServiceName).Scan(&id, &name)
+	}
+	if err != nil {
+		return err
+	}
+
+	sub.ServiceID = &id
+	sub.ServiceName = name
+	return nil
+}
+
+// txQueryRow adapts tx to ensureService.
+func txQueryRow(tx *sql.Tx) func(query string, args ...any) rowScanner {
+	return func(query string, args ...any) rowScanner {
+		return tx.QueryRow(query, args...)
+	}
+}
+
+// querier is implemented by both *sql.DB and *sql.Tx.
+type querier interface {
+	QueryRow(query string, args ...any) *sql.Row
+}
+
+// checkServiceNames fails when the name or an alias of service id is a name or
+// an alias of another service.
+func checkServiceNames(tx *sql.Tx, id int, service types.ServiceRequest) error {
+	names := append([]string{strings.ToLower(service.Name)}, service.Aliases...)
+
+	query := `
+		SELECT EXISTS (
+			SELECT 1 FROM services WHERE lower(Name) = ANY($1) AND ID <> $2
+			UNION ALL
+			SELECT 1 FROM service_aliases WHERE Alias = ANY($1) AND ServiceID <> $2
+		)
+	`
+
+	var taken bool
+	if err := tx.QueryRow(query, names, id).Scan(&taken); err != nil {
+		return err
+	}
+
+	if taken {
+		return apperrors.ServiceNameTaken
+	}
+
+	return nil
+}
+
+func replaceAliases(tx *sql.Tx, id int, aliases []string) error {
+	if _, err := tx.Exec(`DELETE FROM service_aliases WHERE ServiceID = $1`, id); err != nil {
+		return err
+	}
+
+	query := `
+		INSERT INTO service_aliases (Alias, ServiceID)
+		SELECT unnest($1::text[]), $2
+	`
+
+	_, err := tx.Exec(query, aliases, id)
+	return err
+}
+
+// inTx reports unique violations of names and aliases, which checkServiceNames
+// misses under concurrent changes, as ServiceNameTaken.
+func (sr ServicesPostgresRepository) inTx(fn func(tx *sql.Tx) error) error {
+	err := inTx(sr.db, fn)
+	if isUniqueViolation(err) {
+		return apperrors.ServiceNameTaken
+	}
+	return err
+}
+
+// SaveService creates a service, service.Aliases are expected lower-cased.
+func (sr ServicesPostgresRepository) SaveService(service types.ServiceRequest) (types.Service, error) {
+	var res types.Service
+
+	err := sr.inTx(func(tx *sql.Tx) error {
+		if err := checkServiceNames(tx, 0, service); err != nil {
+			return err
+		}
+
+		var id int
+		query := `INSERT INTO services (Name, DefaultPrice) VALUES ($1, $2) RETURNING ID`
+
+		if err := tx.QueryRow(query, service.Name, service.DefaultPrice).Scan(&id); err != nil {
+			return err
+		}
+
+		if err := replaceAliases(tx, id, service.Aliases); err != nil {
+			return err
+		}
+
+		var err error
+		res, err = getService(tx, `sv.ID = $1`, id)
+		return err
+	})
+
+	return res, err
+}
+
+// renameSubscriptions copies the name of service id to its subscriptions, which
+// get a new version and an updated event in their history.
+func renameSubscriptions(tx *sql.Tx, id int, name, actor string) error {
+	lockQuery := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions
+		WHERE ServiceID = $1 AND ServiceName <> $2
+		ORDER BY ID
+		FOR UPDATE
+	`
+
+	rows, err := tx.Query(lockQuery, id, name)
+	if err != nil {
+		return err
+	}
+
+	before, err := scanSubscriptions(rows)
+	rows.Close()
+	if err != nil {
+		return err
+	}
+
+	updateQuery := `
+		UPDATE subscriptions
+		SET ServiceName = $2, Version = Version + 1
+		WHERE ID = $1
+		RETURNING ` + subscriptionColumns
+
+	for i := range before {
+		after, err := scanSubscription(tx.QueryRow(updateQuery, before[i].ID, name))
+		if err != nil {
+			return err
+		}
+
+		if err := insertEvent(tx, types.EventUpdated, actor, &before[i], &after); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// UpdateService replaces a service. A new name is copied to its subscriptions,
+// see renameSubscriptions.
+func (sr ServicesPostgresRepository) UpdateService(id int, service types.ServiceRequest, actor string) (types.Service, error) {
+	var res types.Service
+
+	err := sr.inTx(func(tx *sql.Tx) error {
+		if err := checkServiceNames(tx, id, service); err != nil {
+			return err
+		}
+
+		query := `UPDATE services SET Name = $2, DefaultPrice = $3 WHERE ID = $1`
+
+		updated, err := tx.Exec(query, id, service.Name, service.DefaultPrice)
+		if err != nil {
+			return err
+		}
+
+		if n, err := updated.RowsAffected(); err != nil || n == 0 {
+			if err == nil {
+				err = apperrors.ServiceNotFound
+			}
+			return err
+		}
+
+		if err := replaceAliases(tx, id, service.Aliases); err != nil {
+			return err
+		}
+
+		if err := renameSubscriptions(tx, id, service.Name, actor); err != nil {
+			return err
+		}
+
+		res, err = getService(tx, `sv.ID = $1`, id)
+		return err
+	})
+
+	return res, err
+}
+
+func (sr ServicesPostgresRepository) DeleteService(id int) error {
+	query := `
+		DELETE FROM services
+		WHERE ID = $1 AND NOT EXISTS (SELECT 1 FROM subscriptions WHERE ServiceID = $1)
+	`
+
+	res, err := sr.db.Exec(query, id)
+	if err != nil {
+		return err
+	}
+
+	n, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+
+	if n == 0 {
+		if _, err := sr.GetService(id); err != nil {
+			return err
+		}
+		return apperrors.ServiceInUse
+	}
+
+	return nil
+}
@@ -0,0 +1,306 @@
+package repositories
+
+import (
+	"fmt"
+	"subscriptions-api/internal/types"
+	"time"
+)
+
+// billingEvents counts the charges of a subscription in month m.Month: the
+// billing events of period cp.BillingPeriod falling in that month, counted from
+// the anchor cp.Anchor.
+const billingEvents = `
+	CASE
+		WHEN cp.BillingPeriod = 'weekly' THEN GREATEST(
+			((m.Month + interval '1 month')::date - cp.Anchor + 6) / 7 - (GREATEST(m.Month::date, cp.Anchor) - cp.Anchor + 6) / 7,
+			0
+		)
+		WHEN m.Month < date_trunc('month', cp.Anchor::timestamp) THEN 0
+		WHEN (
+			(EXTRACT(YEAR FROM m.Month) - EXTRACT(YEAR FROM cp.Anchor)) * 12 + EXTRACT(MONTH FROM m.Month) - EXTRACT(MONTH FROM cp.Anchor)
+		)::int % CASE cp.BillingPeriod WHEN 'quarterly' THEN 3 WHEN 'yearly' THEN 12 ELSE 1 END = 0 THEN 1
+		ELSE 0
+	END`
+
+// monthlyFactor scales a price of billing period cp.BillingPeriod to a month.
+const monthlyFactor = `
+	CASE cp.BillingPeriod
+		WHEN 'weekly' THEN 52 / 12.0
+		WHEN 'quarterly' THEN 1 / 3.0
+		WHEN 'yearly' THEN 1 / 12.0
+		ELSE 1
+	END`
+
+// notPaused excludes the months m.Month the subscription is paused in.
+const notPaused = `NOT EXISTS (
+	SELECT 1 FROM subscription_pauses sp
+	WHERE sp.SubscriptionID = s.ID AND sp.StartMonth <= m.Month AND (sp.EndMonth IS NULL OR m.Month < sp.EndMonth)
+)`
+
+// discounted applies the discount d of the month to a price charged in it:
+// nothing is charged during a trial, a percent discount takes its share off and
+// a fixed one its amount, never going below zero.
+func discounted(price string) string {
+	return `CASE d.Type
+		WHEN 'trial' THEN 0
+		WHEN 'percent' THEN ` + price + ` * (100 - d.Value) / 100.0
+		WHEN 'fixed' THEN GREATEST(` + price + ` - d.Value, 0)
+		ELSE ` + price + `
+	END`
+}
+
+// chargesQuery builds a CTE named "charges" with one row per subscription per
+// month it is active within [StartDate, EndDate] of the filter. A missing window
+// start falls back to the subscription start, a missing window end to the current month.
+// Amount is what is billed in the month: the price times the billing events of
+// the month, so a yearly subscription is charged once a year and a weekly one
+// four or five times a month, Events being the number of those billing events.
+// Monthly is the price normalized to a month.
+// Both use the price of every charge less the trial or discount scheduled for
+// the month, if any, so a fixed discount is taken off each billing event.
+// Months the subscription is paused in have no row.
+// Every month is charged at the price, in the currency and on the billing period
+// and anchor of the latest price period effective by then, so Currency is that of the charge. With
+// filter.Currency set, amounts are converted to it and are NULL when an exchange rate is missing.
+func chargesQuery(filter types.StatsFilter) (string, []any) {
+	var qb queryBuilder
+
+	windowStart := qb.arg(nullableTime(filter.StartDate))
+	windowEnd := qb.arg(nullableTime(filter.EndDate))
+
+	convert := func(amount string) string { return `ROUND(` + amount + `)::int` }
+	rates := ""
+
+	if len(filter.Currency) != 0 {
+		currency := qb.arg(filter.Currency)
+		convert = func(amount string) string {
+			return `CASE WHEN cp.Currency = ` + currency + ` THEN ROUND(` + amount + `) ELSE ROUND(` + amount + ` * fr.Rate / tr.Rate) END::int`
+		}
+		rates = `
+			LEFT JOIN exchange_rates fr ON fr.Currency = cp.Currency
+			LEFT JOIN exchange_rates tr ON tr.Currency = ` + currency
+	}
+
+	qb.where("DeletedAt IS NULL")
+	qb.where(notPaused)
+
+	if len(filter.ServiceName) != 0 {
+		qb.where(serviceMatchCond, filter.ServiceName)
+	}
+
+	if len(filter.UserID) != 0 {
+		qb.where("UserID = %s", filter.UserID)
+	}
+
+	query := `
+		WITH charges AS (
+			SELECT s.ID, s.ServiceName, s.UserID, cp.Currency, m.Month::date AS Month, ev.Events,
+				` + convert("dp.Price * ev.Events") + ` AS Amount,
+				` + convert("dp.Price * "+monthlyFactor) + ` AS Monthly
+			FROM subscriptions s
+			CROSS JOIN LATERAL generate_series(
+				GREATEST(StartDate, COALESCE(` + windowStart + `::date, StartDate)),
+				LEAST(COALESCE(EndDate, 'infinity'), COALESCE(` + windowEnd + `::date, date_trunc('month', CURRENT_DATE)::date)),
+				interval '1 month'
+			) AS m(Month)
+			LEFT JOIN LATERAL (
+				SELECT p.Price, p.Currency, p.BillingPeriod, COALESCE(p.BillingAnchor, s.StartDate) AS Anchor
+				FROM subscription_prices p
+				WHERE p.SubscriptionID = s.ID AND p.EffectiveFrom <= m.Month
+				ORDER BY p.EffectiveFrom DESC
+				LIMIT 1
+			) AS pp ON true
+			CROSS JOIN LATERAL (
+				SELECT COALESCE(pp.Price, s.Price) AS Price,
+					COALESCE(pp.Currency, s.Currency) AS Currency,
+					COALESCE(pp.BillingPeriod, s.BillingPeriod) AS BillingPeriod,
+					COALESCE(pp.Anchor, s.BillingAnchor, s.StartDate) AS Anchor
+			) AS cp
+			CROSS JOIN LATERAL (SELECT ` + billingEvents + ` AS Events) AS ev
+			LEFT JOIN LATERAL (
+				SELECT d.Type, d.Value FROM subscription_discounts d
+				WHERE d.SubscriptionID = s.ID AND d.StartMonth <= m.Month
+					AND m.Month < d.StartMonth + d.Months * interval '1 month'
+				ORDER BY d.StartMonth DESC, d.ID DESC
+				LIMIT 1
+			) AS d ON true
+			CROSS JOIN LATERAL (SELECT ` + discounted("cp.Price") + ` AS Price) AS dp` + rates + qb.whereSQL() + `
+		)
+	`
+
+	return query, qb.args
+}
+
+func nullableTime(t *time.Time) any {
+	if t == nil {
+		return nil
+	}
+	return *t
+}
+
+func (sr SubscriptionsPostgresRepository) GetTotalCost(filter types.StatsFilter) (int, error) {
+	query, args := chargesQuery(filter)
+	query += `SELECT COALESCE(SUM(Amount), 0) FROM charges`
+
+	var total int
+	if err := sr.db.QueryRow(query, args...).Scan(&total); err != nil {
+		return 0, err
+	}
+
+	return total, nil
+}
+
+// GetUnconvertedCurrencies returns the currencies of charges that cannot be
+// converted to filter.Currency for lack of an exchange rate.
+func (sr SubscriptionsPostgresRepository) GetUnconvertedCurrencies(filter types.StatsFilter) ([]string, error) {
+	query, args := chargesQuery(filter)
+	query += `SELECT DISTINCT Currency FROM charges WHERE Amount IS NULL ORDER BY Currency`
+
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]string, 0)
+
+	for rows.Next() {
+		var currency string
+
+		if err := rows.Scan(&currency); err != nil {
+			return nil, err
+		}
+
+		result = append(result, currency)
+	}
+
+	return result, rows.Err()
+}
+
+// GetChargesSummary returns the cost normalized to a month, whatever the billing
+// periods, and the number of active subscriptions. It is meant for a one month filter.
+func (sr SubscriptionsPostgresRepository) GetChargesSummary(filter types.StatsFilter) (int, int, error) {
+	query, args := chargesQuery(filter)
+	query += `SELECT COALESCE(SUM(Monthly), 0), COUNT(DISTINCT ID) FROM charges`
+
+	var total, count int
+	if err := sr.db.QueryRow(query, args...).Scan(&total, &count); err != nil {
+		return 0, 0, err
+	}
+
+	return total, count, nil
+}
+
+// breakdownGroups maps a group_by key to the charges column it groups on and
+// the expression rendering that column as the item key.
+var breakdownGroups = map[string]struct{ column, key string }{
+	types.GroupByServiceName: {"ServiceName", "ServiceName"},
+	types.GroupByUserID:      {"UserID", "UserID::text"},
+	types.GroupByMonth:       {"Month", "to_char(Month, 'MM-YYYY')"},
+}
+
+func (sr SubscriptionsPostgresRepository) GetCostBreakdown(groupBy string, filter types.StatsFilter) ([]types.BreakdownItem, error) {
+	group, ok := breakdownGroups[groupBy]
+	if !ok {
+		return nil, fmt.Errorf("unknown group_by %q", groupBy)
+	}
+
+	query, args := chargesQuery(filter)
+	query += fmt.Sprintf(`
+		SELECT %[2]s, SUM(Amount), COUNT(DISTINCT ID)
+		FROM charges
+		GROUP BY %[1]s
+		ORDER BY %[1]s
+	`, group.column, group.key)
+
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.BreakdownItem, 0)
+
+	for rows.Next() {
+		var item types.BreakdownItem
+
+		if err := rows.Scan(&item.Key, &item.Total, &item.Count); err != nil {
+			return nil, err
+		}
+
+		result = append(result, item)
+	}
+
+	return result, rows.Err()
+}
+
+// monthlySeriesQuery selects the month, the amount billed, the billing events
+// and the active subscriptions count of every month of [filter.StartDate,
+// filter.EndDate], months without charges included.
+func monthlySeriesQuery(filter types.StatsFilter) (string, []any) {
+	query, args := chargesQuery(filter)
+	args = append(args, *filter.StartDate, *filter.EndDate)
+
+	query += fmt.Sprintf(`
+		SELECT to_char(g.Month, 'MM-YYYY'), COALESCE(SUM(c.Amount), 0), COALESCE(SUM(c.Events), 0), COUNT(DISTINCT c.ID)
+		FROM generate_series($%d::date, $%d::date, interval '1 month') AS g(Month)
+		LEFT JOIN charges c ON c.Month = g.Month::date
+		GROUP BY g.Month
+		ORDER BY g.Month
+	`, len(args)-1, len(args))
+
+	return query, args
+}
+
+// GetTimeSeries returns a point for every month of [filter.StartDate, filter.EndDate],
+// months without charges included.
+func (sr SubscriptionsPostgresRepository) GetTimeSeries(filter types.StatsFilter) ([]types.TimeSeriesPoint, error) {
+	query, args := monthlySeriesQuery(filter)
+
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.TimeSeriesPoint, 0)
+
+	for rows.Next() {
+		var point types.TimeSeriesPoint
+		var renewals int
+
+		if err := rows.Scan(&point.Month, &point.Total, &renewals, &point.ActiveCount); err != nil {
+			return nil, err
+		}
+
+		result = append(result, point)
+	}
+
+	return result, rows.Err()
+}
+
+// GetForecast returns a point for every month of [filter.StartDate, filter.EndDate]
+// with the number of renewals billed in it.
+func (sr SubscriptionsPostgresRepository) GetForecast(filter types.StatsFilter) ([]types.ForecastPoint, error) {
+	query, args := monthlySeriesQuery(filter)
+
+	rows, err := sr.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.ForecastPoint, 0)
+
+	for rows.Next() {
+		var point types.ForecastPoint
+
+		if err := rows.Scan(&point.Month, &point.Total, &point.Renewals, &point.ActiveCount); err != nil {
+			return nil, err
+		}
+
+		result = append(result, point)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,289 @@
+package repositories
+
+import (
+	"database/sql"
+	"errors"
+	"os"
+	"slices"
+	"subscriptions-api/internal/types"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/golang-migrate/migrate/v4"
+	_ "github.com/golang-migrate/migrate/v4/database/postgres"
+	_ "github.com/golang-migrate/migrate/v4/source/file"
+	"github.com/google/uuid"
+)
+
+var (
+	migrateOnce sync.Once
+	migrateErr  error
+)
+
+// testDB connects to the database of TEST_DATABASE_URL, migrated up, skipping
+// the test when it is not set.
+func testDB(t *testing.T) *sql.DB {
+	t.Helper()
+
+	url := os.Getenv("TEST_DATABASE_URL")
+	if len(url) == 0 {
+		t.Skip("TEST_DATABASE_URL is not set")
+	}
+
+	migrateOnce.Do(func() {
+		m, err := migrate.New("file://../../migrations", url)
+		if err != nil {
+			migrateErr = err
+			return
+		}
+		defer m.Close()
+
+		if err := m.Up(); err != nil && !errors.Is(err, migrate.ErrNoChange) {
+			migrateErr = err
+		}
+	})
+	if migrateErr != nil {
+		t.Fatalf("migrate database: %v", migrateErr)
+	}
+
+	db, err := sql.Open("pgx", url)
+	if err != nil {
+		t.Fatalf("open database: %v", err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	return db
+}
+
+// testSubscription returns a subscription request of a new user to a new
+// service, so the rows it is saved as belong to the test alone.
+func testSubscription(start time.Time, period string, price int) types.SubscriptionRequest {
+	end := types.MonthYear(start.AddDate(1, 0, 0))
+
+	return types.SubscriptionRequest{
+		ServiceName:   "Test " + uuid.NewString(),
+		Price:         &price,
+		Currency:      "RUB",
+		UserID:        uuid.New(),
+		StartDate:     types.MonthYear(start),
+		EndDate:       &end,
+		BillingPeriod: period,
+	}
+}
+
+func TestBillingEvents(t *testing.T) {
+	db := testDB(t)
+
+	// The month is a timestamp, as generate_series yields it in chargesQuery.
+	query := `
+		SELECT ` + billingEvents + `
+		FROM (SELECT $1::text AS BillingPeriod, $2::date AS Anchor) AS cp,
+			(SELECT $3::timestamp AS Month) AS m`
+
+	tests := []struct {
+		name   string
+		period string
+		anchor string
+		month  string
+		want   int
+	}{
+		{name: "monthly anchor month", period: "monthly", anchor: "2025-01-15", month: "2025-01-01", want: 1},
+		{name: "monthly later month", period: "monthly", anchor: "2025-01-15", month: "2025-07-01", want: 1},
+		{name: "monthly before anchor", period: "monthly", anchor: "2025-01-15", month: "2024-12-01", want: 0},
+		{name: "monthly month end into february", period: "monthly", anchor: "2025-01-31", month: "2025-02-01", want: 1},
+		{name: "monthly month end into april", period: "monthly", anchor: "2025-03-31", month: "2025-04-01", want: 1},
+		{name: "quarterly anchor month", period: "quarterly", anchor: "2025-01-31", month: "2025-01-01", want: 1},
+		{name: "quarterly between renewals", period: "quarterly", anchor: "2025-01-31", month: "2025-02-01", want: 0},
+		{name: "quarterly month end renewal", period: "quarterly", anchor: "2025-01-31", month: "2025-04-01", want: 1},
+		{name: "quarterly over new year", period: "quarterly", anchor: "2024-11-30", month: "2025-02-01", want: 1},
+		{name: "yearly anchor month", period: "yearly", anchor: "2025-03-10", month: "2025-03-01", want: 1},
+		{name: "yearly between renewals", period: "yearly", anchor: "2025-03-10", month: "2025-09-01", want: 0},
+		{name: "yearly renewal", period: "yearly", anchor: "2025-03-10", month: "2026-03-01", want: 1},
+		{name: "yearly leap day anchor", period: "yearly", anchor: "2024-02-29", month: "2025-02-01", want: 1},
+		{name: "weekly anchor on first", period: "weekly", anchor: "2025-01-01", month: "2025-01-01", want: 5},
+		{name: "weekly following month", period: "weekly", anchor: "2025-01-01", month: "2025-02-01", want: 4},
+		{name: "weekly anchor on month end", period: "weekly", anchor: "2025-01-31", month: "2025-01-01", want: 1},
+		{name: "weekly after month end anchor", period: "weekly", anchor: "2025-01-31", month: "2025-02-01", want: 4},
+		{name: "weekly two months after month end anchor", period: "weekly", anchor: "2025-01-31", month: "2025-03-01", want: 4},
+		{name: "weekly leap february", period: "weekly", anchor: "2024-02-01", month: "2024-02-01", want: 5},
+		{name: "weekly before anchor", period: "weekly", anchor: "2025-01-31", month: "2024-12-01", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got int
+			if err := db.QueryRow(query, tt.period, tt.anchor, tt.month).Scan(&got); err != nil {
+				t.Fatalf("query billing events: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("%s billing events of %s anchored at %s = %d, want %d", tt.period, tt.month, tt.anchor, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMonthlyFactor(t *testing.T) {
+	db := testDB(t)
+
+	query := `SELECT ROUND($2::int * ` + monthlyFactor + `)::int FROM (SELECT $1::text AS BillingPeriod) AS cp`
+
+	tests := []struct {
+		period string
+		price  int
+		want   int
+	}{
+		{period: "weekly", price: 300, want: 1300},
+		{period: "monthly", price: 999, want: 999},
+		{period: "quarterly", price: 2997, want: 999},
+		{period: "yearly", price: 11988, want: 999},
+		{period: "yearly", price: 1000, want: 83},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.period, func(t *testing.T) {
+			var got int
+			if err := db.QueryRow(query, tt.period, tt.price).Scan(&got); err != nil {
+				t.Fatalf("query monthly factor: %v", err)
+			}
+
+			if got != tt.want {
+				t.Errorf("monthly amount of %s price %d = %d, want %d", tt.period, tt.price, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChargesDiscounts(t *testing.T) {
+	db := testDB(t)
+
+	month := func(m time.Month) time.Time { return time.Date(2025, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	type discount struct {
+		kind   string
+		value  int
+		start  time.Time
+		months int
+	}
+
+	tests := []struct {
+		name      string
+		period    string
+		price     int
+		discounts []discount
+		// want is the amount charged in each month from January to June.
+		want []int
+	}{
+		{name: "no discount", period: types.BillingMonthly, price: 1000, want: []int{1000, 1000, 1000, 1000, 1000, 1000}},
+		{
+			name:      "trial",
+			period:    types.BillingMonthly,
+			price:     1000,
+			discounts: []discount{{kind: types.DiscountTrial, start: month(time.January), months: 2}},
+			want:      []int{0, 0, 1000, 1000, 1000, 1000},
+		},
+		{
+			name:      "percent",
+			period:    types.BillingMonthly,
+			price:     1000,
+			discounts: []discount{{kind: types.DiscountPercent, value: 25, start: month(time.March), months: 2}},
+			want:      []int{1000, 1000, 750, 750, 1000, 1000},
+		},
+		{
+			name:      "fixed",
+			period:    types.BillingMonthly,
+			price:     1000,
+			discounts: []discount{{kind: types.DiscountFixed, value: 300, start: month(time.June), months: 1}},
+			want:      []int{1000, 1000, 1000, 1000, 1000, 700},
+		},
+		{
+			name:      "fixed above the price",
+			period:    types.BillingMonthly,
+			price:     1000,
+			discounts: []discount{{kind: types.DiscountFixed, value: 1500, start: month(time.January), months: 1}},
+			want:      []int{0, 1000, 1000, 1000, 1000, 1000},
+		},
+		{
+			name:   "trial then percent",
+			period: types.BillingMonthly,
+			price:  1000,
+			discounts: []discount{
+				{kind: types.DiscountTrial, start: month(time.January), months: 1},
+				{kind: types.DiscountPercent, value: 50, start: month(time.February), months: 3},
+			},
+			want: []int{0, 500, 500, 500, 1000, 1000},
+		},
+		{
+			name:      "fixed off each weekly charge",
+			period:    types.BillingWeekly,
+			price:     100,
+			discounts: []discount{{kind: types.DiscountFixed, value: 30, start: month(time.January), months: 2}},
+			want:      []int{350, 280, 400, 500, 400, 400},
+		},
+		{
+			name:      "trial over a yearly renewal",
+			period:    types.BillingYearly,
+			price:     12000,
+			discounts: []discount{{kind: types.DiscountTrial, start: month(time.January), months: 1}},
+			want:      []int{0, 0, 0, 0, 0, 0},
+		},
+		{
+			name:      "percent off a yearly renewal",
+			period:    types.BillingYearly,
+			price:     12000,
+			discounts: []discount{{kind: types.DiscountPercent, value: 10, start: month(time.January), months: 1}},
+			want:      []int{10800, 0, 0, 0, 0, 0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx, err := db.Begin()
+			if err != nil {
+				t.Fatalf("begin: %v", err)
+			}
+			defer tx.Rollback()
+
+			sub, err := saveSubscription(tx, testSubscription(month(time.January), tt.period, tt.price), "test")
+			if err != nil {
+				t.Fatalf("save subscription: %v", err)
+			}
+
+			for _, d := range tt.discounts {
+				_, err := tx.Exec(
+					`INSERT INTO subscription_discounts (SubscriptionID, Type, Value, StartMonth, Months) VALUES ($1, $2, $3, $4, $5)`,
+					sub.ID, d.kind, d.value, d.start, d.months,
+				)
+				if err != nil {
+					t.Fatalf("insert %s discount: %v", d.kind, err)
+				}
+			}
+
+			start, end := month(time.January), month(time.June)
+			query, args := chargesQuery(types.StatsFilter{UserID: sub.UserID, StartDate: &start, EndDate: &end})
+
+			rows, err := tx.Query(query+`SELECT Amount FROM charges ORDER BY Month`, args...)
+			if err != nil {
+				t.Fatalf("query charges: %v", err)
+			}
+			defer rows.Close()
+
+			var got []int
+			for rows.Next() {
+				var amount int
+				if err := rows.Scan(&amount); err != nil {
+					t.Fatalf("scan charge: %v", err)
+				}
+				got = append(got, amount)
+			}
+			if err := rows.Err(); err != nil {
+				t.Fatalf("read charges: %v", err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("charges = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,153 @@
+package repositories
+
+import (
+	"database/sql"
+	"slices"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+	"time"
+)
+
+// checkDates rejects a new start or end of a subscription that is not active:
+// those only move through pause, resume and cancel, which keep the status and
+// the pauses consistent with the dates.
+func checkDates(before types.SubscriptionResponse, start types.MonthYear, end *types.MonthYear) error {
+	if before.Status == types.StatusActive {
+		return nil
+	}
+
+	sameEnd := end == nil && before.EndDate == nil ||
+		end != nil && before.EndDate != nil && time.Time(*end).Equal(*before.EndDate)
+
+	if !time.Time(start).Equal(before.StartDate) || !sameEnd {
+		return apperrors.DatesLocked
+	}
+
+	return nil
+}
+
+// transition changes the status of a subscription whose status is one of from,
+// running fn in the same transaction before the status is written.
+func (sr SubscriptionsPostgresRepository) transition(
+	id int,
+	action, event, status string,
+	from []string,
+	expectedVersion *int,
+	actor string,
+	fn func(tx *sql.Tx) error,
+) (types.SubscriptionResponse, error) {
+	query := `
+		UPDATE subscriptions
+		SET Status = $2, Version = Version + 1
+		WHERE ID = $1
+		RETURNING ` + subscriptionColumns
+
+	return sr.change(id, event, actor, false, expectedVersion, func(tx *sql.Tx, before types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		if !slices.Contains(from, before.Status) {
+			return types.SubscriptionResponse{}, apperrors.InvalidTransition(action, before.Status)
+		}
+
+		if err := fn(tx); err != nil {
+			return types.SubscriptionResponse{}, err
+		}
+
+		return scanSubscription(tx.QueryRow(query, id, status))
+	})
+}
+
+// PauseSubscription stops charging an active subscription from the current
+// month, or from its start if it has not started yet.
+func (sr SubscriptionsPostgresRepository) PauseSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	query := `
+		INSERT INTO subscription_pauses (SubscriptionID, StartMonth)
+		SELECT ID, GREATEST(StartDate, date_trunc('month', CURRENT_DATE)::date)
+		FROM subscriptions
+		WHERE ID = $1
+	`
+
+	return sr.transition(id, "pause", types.EventPaused, types.StatusPaused, []string{types.StatusActive}, expectedVersion, actor, func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, id)
+		return err
+	})
+}
+
+// ResumeSubscription charges a paused subscription again from the current month.
+func (sr SubscriptionsPostgresRepository) ResumeSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	query := `
+		UPDATE subscription_pauses
+		SET EndMonth = GREATEST(StartMonth, date_trunc('month', CURRENT_DATE)::date)
+		WHERE SubscriptionID = $1 AND EndMonth IS NULL
+	`
+
+	return sr.transition(id, "resume", types.EventResumed, types.StatusActive, []string{types.StatusPaused}, expectedVersion, actor, func(tx *sql.Tx) error {
+		_, err := tx.Exec(query, id)
+		return err
+	})
+}
+
+// CancelSubscription ends an active or paused subscription with the current
+// month, unless it ends earlier. A pause in progress is left open, the months
+// after the end are not charged anyway. A subscription that has not started yet
+// ends with its start month, which is left unbilled by a pause open from it.
+func (sr SubscriptionsPostgresRepository) CancelSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	pauseQuery := `
+		INSERT INTO subscription_pauses (SubscriptionID, StartMonth)
+		SELECT ID, StartDate
+		FROM subscriptions s
+		WHERE ID = $1 AND StartDate > date_trunc('month', CURRENT_DATE)
+			AND NOT EXISTS (SELECT 1 FROM subscription_pauses sp WHERE sp.SubscriptionID = s.ID AND sp.EndMonth IS NULL)
+	`
+
+	endQuery := `
+		UPDATE subscriptions
+		SET EndDate = GREATEST(StartDate, LEAST(COALESCE(EndDate, 'infinity'), date_trunc('month', CURRENT_DATE)::date))
+		WHERE ID = $1
+	`
+
+	return sr.transition(id, "cancel", types.EventCancelled, types.StatusCancelled, []string{types.StatusActive, types.StatusPaused}, expectedVersion, actor, func(tx *sql.Tx) error {
+		if _, err := tx.Exec(pauseQuery, id); err != nil {
+			return err
+		}
+
+		_, err := tx.Exec(endQuery, id)
+		return err
+	})
+}
+
+func (sr SubscriptionsPostgresRepository) GetPauses(id int) ([]types.PausePeriod, error) {
+	if _, err := sr.GetSubscription(id); err != nil {
+		return nil, err
+	}
+
+	query := `
+		SELECT StartMonth, EndMonth
+		FROM subscription_pauses
+		WHERE SubscriptionID = $1
+		ORDER BY StartMonth, ID
+	`
+
+	rows, err := sr.db.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	result := make([]types.PausePeriod, 0)
+
+	for rows.Next() {
+		var period types.PausePeriod
+		var endMonth sql.NullTime
+
+		if err := rows.Scan(&period.StartMonth, &endMonth); err != nil {
+			return nil, err
+		}
+
+		if endMonth.Valid {
+			period.EndMonth = &endMonth.Time
+		}
+
+		result = append(result, period)
+	}
+
+	return result, rows.Err()
+}
@@ -0,0 +1,83 @@
+package repositories
+
+import (
+	"subscriptions-api/internal/types"
+	"testing"
+	"time"
+)
+
+func TestCancelSubscription(t *testing.T) {
+	sr := NewSubscriptionsPostgresRepository(testDB(t))
+
+	now := time.Now().UTC()
+	thisMonth := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name       string
+		start      time.Time
+		pauseFirst bool
+		// wantMonths is the number of months charged, wantPauses those of the pauses.
+		wantMonths int
+		wantPauses int
+	}{
+		{name: "started", start: thisMonth.AddDate(0, -2, 0), wantMonths: 3},
+		{name: "starts this month", start: thisMonth, wantMonths: 1},
+		{name: "not started", start: thisMonth.AddDate(0, 2, 0), wantPauses: 1},
+		{name: "paused before the start", start: thisMonth.AddDate(0, 2, 0), pauseFirst: true, wantPauses: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sub, err := sr.SaveSubscription(testSubscription(tt.start, types.BillingMonthly, 1000), "test")
+			if err != nil {
+				t.Fatalf("save subscription: %v", err)
+			}
+
+			if tt.pauseFirst {
+				if _, err := sr.PauseSubscription(sub.ID, nil, "test"); err != nil {
+					t.Fatalf("pause subscription: %v", err)
+				}
+			}
+
+			cancelled, err := sr.CancelSubscription(sub.ID, nil, "test")
+			if err != nil {
+				t.Fatalf("cancel subscription: %v", err)
+			}
+
+			wantEnd := tt.start
+			if wantEnd.Before(thisMonth) {
+				wantEnd = thisMonth
+			}
+			if cancelled.EndDate == nil || !cancelled.EndDate.Equal(wantEnd) {
+				t.Errorf("end date = %v, want %v", cancelled.EndDate, wantEnd)
+			}
+
+			// The window reaches past the start, so months after the current one count.
+			windowEnd := tt.start.AddDate(1, 0, 0)
+			query, args := chargesQuery(types.StatsFilter{UserID: sub.UserID, EndDate: &windowEnd})
+
+			var months, total int
+			if err := sr.db.QueryRow(query+`SELECT COUNT(*), COALESCE(SUM(Amount), 0) FROM charges`, args...).Scan(&months, &total); err != nil {
+				t.Fatalf("query charges: %v", err)
+			}
+
+			if months != tt.wantMonths || total != tt.wantMonths*1000 {
+				t.Errorf("charged %d months for %d, want %d months for %d", months, total, tt.wantMonths, tt.wantMonths*1000)
+			}
+
+			pauses, err := sr.GetPauses(sub.ID)
+			if err != nil {
+				t.Fatalf("get pauses: %v", err)
+			}
+
+			if len(pauses) != tt.wantPauses {
+				t.Fatalf("pauses = %+v, want %d", pauses, tt.wantPauses)
+			}
+			for _, p := range pauses {
+				if !p.StartMonth.Equal(tt.start) || p.EndMonth != nil {
+					t.Errorf("pause = %+v, want one open from %v", p, tt.start)
+				}
+			}
+		})
+	}
+}
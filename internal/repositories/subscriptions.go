@@ -3,19 +3,43 @@ package repositories
 import (
 	"database/sql"
 	"errors"
-	"fmt"
+	"strings"
 	"subscriptions-api/internal/apperrors"
 	"subscriptions-api/internal/types"
 	"time"
 )
 
 type SubscriptionsRepository interface {
-	SaveSubscription(sub types.SubscriptionRequest) (types.SubscriptionResponse, error)
+	SaveSubscription(sub types.SubscriptionRequest, actor string) (types.SubscriptionResponse, error)
 	GetSubscription(id int) (types.SubscriptionResponse, error)
-	GetSubscriptions(offset int, count int) ([]types.SubscriptionResponse, error)
-	GetSubscriptionsByFilter(serviceName, userID string, startDate, endDate *time.Time) ([]types.SubscriptionResponse, error)
-	UpdateSubscription(id int, sub types.SubscriptionRequest) (types.SubscriptionResponse, error)
-	DeleteSubscription(id int) (types.SubscriptionResponse, error)
+	GetSubscriptions(filter types.SubscriptionsFilter, sort []types.SortField, offset int, count int) ([]types.SubscriptionResponse, error)
+	CountSubscriptions(filter types.SubscriptionsFilter) (int, error)
+	GetSubscriptionsAfter(filter types.SubscriptionsFilter, sort []types.SortField, cursor *types.Cursor, count int) ([]types.SubscriptionResponse, error)
+	ExportSubscriptions(filter types.SubscriptionsFilter, sort []types.SortField, fn func(types.SubscriptionResponse) error) error
+	UpdateSubscription(id int, sub types.SubscriptionRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	PatchSubscription(id int, patch types.SubscriptionPatch, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	DeleteSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	RestoreSubscription(id int, actor string) (types.SubscriptionResponse, error)
+	GetSubscriptionHistory(id int) ([]types.SubscriptionEvent, error)
+	AddPriceChange(id int, change types.PriceChangeRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	GetPriceHistory(id int) ([]types.PricePeriod, error)
+	PauseSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	ResumeSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	CancelSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	GetPauses(id int) ([]types.PausePeriod, error)
+	AddDiscount(id int, req types.DiscountRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, types.Discount, error)
+	GetDiscounts(id int) ([]types.Discount, error)
+	DeleteDiscount(id, discountID int, expectedVersion *int, actor string) (types.SubscriptionResponse, error)
+	SaveSubscriptions(subs []types.SubscriptionRequest, actor string) ([]types.SubscriptionResponse, []error, error)
+	ImportSubscriptions(actor string, fn func(tx ImportTx) (bool, error)) (bool, error)
+	UpdateSubscriptions(items []types.SubscriptionUpdateItem, actor string) ([]types.SubscriptionResponse, []error, error)
+	DeleteSubscriptions(ids []int, actor string) ([]types.SubscriptionResponse, []error, error)
+	GetTotalCost(filter types.StatsFilter) (int, error)
+	GetChargesSummary(filter types.StatsFilter) (int, int, error)
+	GetUnconvertedCurrencies(filter types.StatsFilter) ([]string, error)
+	GetCostBreakdown(groupBy string, filter types.StatsFilter) ([]types.BreakdownItem, error)
+	GetTimeSeries(filter types.StatsFilter) ([]types.TimeSeriesPoint, error)
+	GetForecast(filter types.StatsFilter) ([]types.ForecastPoint, error)
 }
 
 type SubscriptionsPostgresRepository struct {
@@ -26,230 +50,437 @@ func NewSubscriptionsPostgresRepository(db *sql.DB) SubscriptionsPostgresReposit
 	return SubscriptionsPostgresRepository{db}
 }
 
-func (sr SubscriptionsPostgresRepository) SaveSubscription(sub types.SubscriptionRequest) (types.SubscriptionResponse, error) {
-	query := `
-		INSERT INTO subscriptions 
-		(ServiceName, Price, UserID, StartDate) 
-		VALUES ($1, $2, $3, $4) 
-		RETURNING ID, ServiceName, Price, UserID, StartDate
-	`
+const subscriptionColumns = "ID, ServiceID, ServiceName, Price, Currency, UserID, StartDate, EndDate, BillingPeriod, BillingAnchor, Status, Version, DeletedAt"
 
-	r := sr.db.QueryRow(
-		query,
-		sub.ServiceName,
-		sub.Price,
-		sub.UserID,
-		time.Time(sub.StartDate),
-	)
+type rowScanner interface {
+	Scan(dest ...any) error
+}
 
-	var id, price int
-	var serviceName, userID string
+func scanSubscription(r rowScanner) (types.SubscriptionResponse, error) {
+	var id, serviceID, price, version int
+	var serviceName, currency, userID, billingPeriod, status string
 	var startDate time.Time
+	var endDate, billingAnchor, deletedAt sql.NullTime
 
-	if err := r.Scan(&id, &serviceName, &price, &userID, &startDate); err != nil {
+	if err := r.Scan(&id, &serviceID, &serviceName, &price, &currency, &userID, &startDate, &endDate, &billingPeriod, &billingAnchor, &status, &version, &deletedAt); err != nil {
 		return types.SubscriptionResponse{}, err
 	}
 
 	res := types.SubscriptionResponse{
-		ID:          id,
-		ServiceName: serviceName,
-		Price:       price,
-		UserID:      userID,
-		StartDate:   startDate,
+		ID:            id,
+		ServiceID:     serviceID,
+		ServiceName:   serviceName,
+		Price:         price,
+		Currency:      currency,
+		UserID:        userID,
+		StartDate:     startDate,
+		BillingPeriod: billingPeriod,
+		Status:        status,
+		Version:       version,
+	}
+
+	if endDate.Valid {
+		res.EndDate = &endDate.Time
+	}
+
+	if billingAnchor.Valid {
+		res.BillingAnchor = &billingAnchor.Time
+	}
+
+	if deletedAt.Valid {
+		res.DeletedAt = &deletedAt.Time
 	}
 
 	return res, nil
 }
 
+func scanSubscriptions(rows *sql.Rows) ([]types.SubscriptionResponse, error) {
+	result := make([]types.SubscriptionResponse, 0)
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return nil, err
+		}
+
+		result = append(result, sub)
+	}
+
+	return result, rows.Err()
+}
+
+func nullableMonth(m *types.MonthYear) any {
+	if m == nil {
+		return nil
+	}
+	return time.Time(*m)
+}
+
+func nullableDate(d *types.Date) any {
+	if d == nil {
+		return nil
+	}
+	return time.Time(*d)
+}
+
+const insertSubscriptionQuery = `
+	INSERT INTO subscriptions 
+	(ServiceName, Price, UserID, StartDate, EndDate, Currency, ServiceID, BillingPeriod, BillingAnchor) 
+	VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9) 
+	RETURNING ` + subscriptionColumns
+
+// insertSubscriptionArgs expects a request with the price set whose service
+// has been resolved, see ensureService.
+func insertSubscriptionArgs(sub types.SubscriptionRequest) []any {
+	return []any{sub.ServiceName, *sub.Price, sub.UserID, time.Time(sub.StartDate), nullableMonth(sub.EndDate), sub.Currency, *sub.ServiceID, sub.BillingPeriod, nullableDate(sub.BillingAnchor)}
+}
+
+// saveSubscription creates a subscription, and the service of an unknown name, in tx.
+func saveSubscription(tx *sql.Tx, sub types.SubscriptionRequest, actor string) (types.SubscriptionResponse, error) {
+	if err := ensureService(txQueryRow(tx), &sub); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	res, err := scanSubscription(tx.QueryRow(insertSubscriptionQuery, insertSubscriptionArgs(sub)...))
+	if err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	if err := recordInitialPrice(tx, res); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	return res, insertEvent(tx, types.EventCreated, actor, nil, &res)
+}
+
+func (sr SubscriptionsPostgresRepository) SaveSubscription(sub types.SubscriptionRequest, actor string) (types.SubscriptionResponse, error) {
+	var res types.SubscriptionResponse
+
+	err := sr.inTx(func(tx *sql.Tx) error {
+		var err error
+		res, err = saveSubscription(tx, sub, actor)
+		return err
+	})
+
+	return res, err
+}
+
+// ImportTx is the transaction of an import. Services are looked up and
+// subscriptions saved with the connection of the transaction.
+type ImportTx interface {
+	GetService(id int) (types.Service, error)
+	FindService(name string) (types.Service, error)
+	Save(sub types.SubscriptionRequest) (int, error)
+}
+
+type importTx struct {
+	tx    *sql.Tx
+	actor string
+}
+
+func (it importTx) GetService(id int) (types.Service, error) {
+	return getService(it.tx, serviceByIDCond, id)
+}
+
+func (it importTx) FindService(name string) (types.Service, error) {
+	return getService(it.tx, serviceByNameCond, name)
+}
+
+func (it importTx) Save(sub types.SubscriptionRequest) (int, error) {
+	res, err := saveSubscription(it.tx, sub, it.actor)
+	return res.ID, err
+}
+
+// errImportRolledBack rolls back an import that is not to be committed.
+var errImportRolledBack = errors.New("import rolled back")
+
+// ImportSubscriptions runs fn in one transaction. The transaction is committed
+// only when fn returns true, ImportSubscriptions then reports it committed.
+func (sr SubscriptionsPostgresRepository) ImportSubscriptions(actor string, fn func(tx ImportTx) (bool, error)) (bool, error) {
+	err := sr.inTx(func(tx *sql.Tx) error {
+		commit, err := fn(importTx{tx: tx, actor: actor})
+		if err != nil {
+			return err
+		}
+
+		if !commit {
+			return errImportRolledBack
+		}
+		return nil
+	})
+
+	if errors.Is(err, errImportRolledBack) {
+		return false, nil
+	}
+
+	return err == nil, err
+}
+
 func (sr SubscriptionsPostgresRepository) GetSubscription(id int) (types.SubscriptionResponse, error) {
 	query := `
-		SELECT ServiceName, Price, UserID, StartDate 
+		SELECT ` + subscriptionColumns + `
 		FROM subscriptions
-		WHERE id = $1
+		WHERE id = $1 AND DeletedAt IS NULL
 	`
 
-	r := sr.db.QueryRow(query, id)
-
-	var serviceName, userID string
-	var price int
-	var startDate time.Time
-
-	if err := r.Scan(&serviceName, &price, &userID, &startDate); err != nil {
+	res, err := scanSubscription(sr.db.QueryRow(query, id))
+	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
 			return types.SubscriptionResponse{}, apperrors.SubscriptionNotFound
 		}
 		return types.SubscriptionResponse{}, err
 	}
 
-	return types.SubscriptionResponse{
-		ID:          id,
-		ServiceName: serviceName,
-		Price:       price,
-		UserID:      userID,
-		StartDate:   startDate,
-	}, nil
+	return res, nil
 }
 
-func (sr SubscriptionsPostgresRepository) GetSubscriptions(offset int, limit int) ([]types.SubscriptionResponse, error) {
+func (sr SubscriptionsPostgresRepository) GetSubscriptions(filter types.SubscriptionsFilter, sort []types.SortField, offset int, limit int) ([]types.SubscriptionResponse, error) {
+	var qb queryBuilder
+	qb.filterSubscriptions(filter)
+
 	query := `
-		SELECT id, ServiceName, Price, UserID, StartDate 
-		FROM subscriptions
-		OFFSET $1 LIMIT $2
-	`
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions` + qb.whereSQL() + orderBySQL(sort) +
+		" OFFSET " + qb.arg(offset) + " LIMIT " + qb.arg(limit)
 
-	rows, err := sr.db.Query(query, offset, limit)
+	rows, err := sr.db.Query(query, qb.args...)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 
-	result := make([]types.SubscriptionResponse, 0)
+	return scanSubscriptions(rows)
+}
 
-	for rows.Next() {
-		var id, price int
-		var serviceName, userID string
-		var startDate time.Time
+func (sr SubscriptionsPostgresRepository) CountSubscriptions(filter types.SubscriptionsFilter) (int, error) {
+	var qb queryBuilder
+	qb.filterSubscriptions(filter)
 
-		err := rows.Scan(&id, &serviceName, &price, &userID, &startDate)
-		if err != nil {
-			return nil, err
-		}
+	query := `SELECT COUNT(*) FROM subscriptions` + qb.whereSQL()
 
-		result = append(
-			result,
-			types.SubscriptionResponse{
-				ID:          id,
-				ServiceName: serviceName,
-				Price:       price,
-				UserID:      userID,
-				StartDate:   startDate,
-			},
-		)
+	var total int
+	if err := sr.db.QueryRow(query, qb.args...).Scan(&total); err != nil {
+		return 0, err
 	}
 
-	return result, nil
-
+	return total, nil
 }
 
-func (sr SubscriptionsPostgresRepository) UpdateSubscription(id int, sub types.SubscriptionRequest) (types.SubscriptionResponse, error) {
-	query := `
-		UPDATE subscriptions
-		SET ServiceName=$2, Price=$3, UserID=$4, StartDate=$5
-		WHERE id=$1
-		RETURNING ServiceName, Price, UserID, StartDate
-	`
+func (sr SubscriptionsPostgresRepository) GetSubscriptionsAfter(filter types.SubscriptionsFilter, sort []types.SortField, cursor *types.Cursor, limit int) ([]types.SubscriptionResponse, error) {
+	var qb queryBuilder
+	qb.filterSubscriptions(filter)
 
-	r := sr.db.QueryRow(query, id, sub.ServiceName, sub.Price, sub.UserID, time.Time(sub.StartDate))
+	if cursor != nil {
+		qb.after(sort, *cursor)
+	}
 
-	var price int
-	var serviceName, userID string
-	var startDate time.Time
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions` + qb.whereSQL() + orderBySQL(sort) +
+		" LIMIT " + qb.arg(limit)
 
-	if err := r.Scan(&serviceName, &price, &userID, &startDate); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return types.SubscriptionResponse{}, apperrors.SubscriptionNotFound
-		}
-		return types.SubscriptionResponse{}, err
+	rows, err := sr.db.Query(query, qb.args...)
+	if err != nil {
+		return nil, err
 	}
+	defer rows.Close()
 
-	return types.SubscriptionResponse{
-		ID:          id,
-		ServiceName: serviceName,
-		Price:       price,
-		UserID:      userID,
-		StartDate:   startDate,
-	}, nil
+	return scanSubscriptions(rows)
 }
 
-func (sr SubscriptionsPostgresRepository) DeleteSubscription(id int) (types.SubscriptionResponse, error) {
-	query := `
-		DELETE FROM subscriptions
-		WHERE id=$1
-		RETURNING ServiceName, Price, UserID, StartDate
-	`
+const updateSubscriptionQuery = `
+	UPDATE subscriptions
+	SET ServiceName=$2, Price=$3, UserID=$4, StartDate=$5, EndDate=$6, Currency=$7, ServiceID=$8, BillingPeriod=$9, BillingAnchor=$10, Version=Version+1
+	WHERE id=$1
+	RETURNING ` + subscriptionColumns
 
-	r := sr.db.QueryRow(query, id)
+func updateSubscriptionArgs(id int, sub types.SubscriptionRequest) []any {
+	return append([]any{id}, insertSubscriptionArgs(sub)...)
+}
 
-	var price int
-	var serviceName, userID string
-	var startDate time.Time
+func (sr SubscriptionsPostgresRepository) UpdateSubscription(id int, sub types.SubscriptionRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return sr.change(id, types.EventUpdated, actor, false, expectedVersion, func(tx *sql.Tx, before types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		if err := checkDates(before, sub.StartDate, sub.EndDate); err != nil {
+			return types.SubscriptionResponse{}, err
+		}
 
-	if err := r.Scan(&serviceName, &price, &userID, &startDate); err != nil {
-		if errors.Is(err, sql.ErrNoRows) {
-			return types.SubscriptionResponse{}, apperrors.SubscriptionNotFound
+		if err := ensureService(txQueryRow(tx), &sub); err != nil {
+			return types.SubscriptionResponse{}, err
 		}
-		return types.SubscriptionResponse{}, err
-	}
 
-	return types.SubscriptionResponse{
-		ID:          id,
-		ServiceName: serviceName,
-		Price:       price,
-		UserID:      userID,
-		StartDate:   startDate,
-	}, nil
+		res, err := scanSubscription(tx.QueryRow(updateSubscriptionQuery, updateSubscriptionArgs(id, sub)...))
+		if err != nil {
+			return res, err
+		}
+
+		return res, updatePricePeriods(tx, before, res)
+	})
 }
 
-func (sr SubscriptionsPostgresRepository) GetSubscriptionsByFilter(serviceName, userID string, startDate, endDate *time.Time) ([]types.SubscriptionResponse, error) {
-	query := `
-		SELECT id, ServiceName, Price, userID, StartDate
-		FROM subscriptions WHERE 1=1
-	`
+// patchSubscriptionQuery builds the update of the columns supplied by patch,
+// or returns an empty query when there are none.
+func patchSubscriptionQuery(id int, patch types.SubscriptionPatch) (string, []any) {
+	var qb queryBuilder
+	sets := make([]string, 0, 10)
 
-	args := make([]interface{}, 0, 3)
-	argc := 1
+	if patch.ServiceID.Set {
+		sets = append(sets, "ServiceID = "+qb.arg(*patch.ServiceID.Value))
+	}
 
-	if len(serviceName) != 0 {
-		query += fmt.Sprintf(" AND ServiceName=$%d", argc)
-		args = append(args, serviceName)
-		argc++
+	if patch.ServiceName.Set {
+		sets = append(sets, "ServiceName = "+qb.arg(*patch.ServiceName.Value))
 	}
 
-	if len(userID) != 0 {
-		query += fmt.Sprintf(" AND UserID=$%d", argc)
-		args = append(args, userID)
-		argc++
+	if patch.Price.Set {
+		sets = append(sets, "Price = "+qb.arg(*patch.Price.Value))
 	}
 
-	if startDate != nil {
-		query += fmt.Sprintf(" AND StartDate >= $%d", argc)
-		args = append(args, *startDate)
-		argc++
+	if patch.Currency.Set {
+		sets = append(sets, "Currency = "+qb.arg(*patch.Currency.Value))
 	}
 
-	if endDate != nil {
-		query += fmt.Sprintf(" AND StartDate <= $%d", argc)
-		args = append(args, *endDate)
-		argc++
+	if patch.UserID.Set {
+		sets = append(sets, "UserID = "+qb.arg(*patch.UserID.Value))
 	}
 
-	rows, err := sr.db.Query(query, args...)
-	if err != nil {
-		return nil, err
+	if patch.StartDate.Set {
+		sets = append(sets, "StartDate = "+qb.arg(time.Time(*patch.StartDate.Value)))
 	}
-	defer rows.Close()
 
-	result := make([]types.SubscriptionResponse, 0)
+	if patch.EndDate.Set {
+		sets = append(sets, "EndDate = "+qb.arg(nullableMonth(patch.EndDate.Value)))
+	}
 
-	for rows.Next() {
-		var ID, Price int
-		var ServiceName, UserID string
-		var StartDate time.Time
+	if patch.BillingPeriod.Set {
+		sets = append(sets, "BillingPeriod = "+qb.arg(*patch.BillingPeriod.Value))
+	}
+
+	if patch.BillingAnchor.Set {
+		sets = append(sets, "BillingAnchor = "+qb.arg(nullableDate(patch.BillingAnchor.Value)))
+	}
+
+	if len(sets) == 0 {
+		return "", nil
+	}
+
+	sets = append(sets, "Version = Version + 1")
+	qb.where("ID = %s", id)
+
+	query := `
+		UPDATE subscriptions
+		SET ` + strings.Join(sets, ", ") + qb.whereSQL() + `
+		RETURNING ` + subscriptionColumns
+
+	return query, qb.args
+}
 
-		err := rows.Scan(&ID, &ServiceName, &Price, &UserID, &StartDate)
+// patchDates returns the start and end dates of before with the patch applied.
+func patchDates(before types.SubscriptionResponse, patch types.SubscriptionPatch) (types.MonthYear, *types.MonthYear) {
+	start, end := types.MonthYear(before.StartDate), (*types.MonthYear)(before.EndDate)
 
+	if patch.StartDate.Set && patch.StartDate.Value != nil {
+		start = *patch.StartDate.Value
+	}
+
+	if patch.EndDate.Set {
+		end = patch.EndDate.Value
+	}
+
+	return start, end
+}
+
+// PatchSubscription updates only the columns supplied by the patch. A service
+// name patched without a service id is resolved, and created if unknown, in
+// the transaction of the update.
+func (sr SubscriptionsPostgresRepository) PatchSubscription(id int, patch types.SubscriptionPatch, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	query, args := patchSubscriptionQuery(id, patch)
+
+	if len(query) == 0 {
+		res, err := sr.GetSubscription(id)
+		if err == nil && expectedVersion != nil && res.Version != *expectedVersion {
+			return types.SubscriptionResponse{}, apperrors.VersionMismatch
+		}
+		return res, err
+	}
+
+	return sr.change(id, types.EventUpdated, actor, false, expectedVersion, func(tx *sql.Tx, before types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		start, end := patchDates(before, patch)
+		if err := checkDates(before, start, end); err != nil {
+			return types.SubscriptionResponse{}, err
+		}
+
+		if patch.ServiceName.Set && !patch.ServiceID.Set {
+			sub := types.SubscriptionRequest{ServiceName: *patch.ServiceName.Value}
+			if err := ensureService(txQueryRow(tx), &sub); err != nil {
+				return types.SubscriptionResponse{}, err
+			}
+
+			patch.ServiceID = types.Optional[int]{Set: true, Value: sub.ServiceID}
+			patch.ServiceName = types.Optional[string]{Set: true, Value: &sub.ServiceName}
+			query, args = patchSubscriptionQuery(id, patch)
+		}
+
+		res, err := scanSubscription(tx.QueryRow(query, args...))
 		if err != nil {
-			return nil, err
+			return res, err
 		}
 
-		result = append(result, types.SubscriptionResponse{
-			ID:          ID,
-			ServiceName: ServiceName,
-			UserID:      UserID,
-			Price:       Price,
-			StartDate:   StartDate,
-		})
+		return res, updatePricePeriods(tx, before, res)
+	})
+}
+
+const deleteSubscriptionQuery = `
+	UPDATE subscriptions
+	SET DeletedAt=now(), Version=Version+1
+	WHERE id=$1
+	RETURNING ` + subscriptionColumns
+
+// DeleteSubscription soft-deletes a subscription: it is kept with DeletedAt set
+// and excluded from every query unless deleted rows are asked for explicitly.
+func (sr SubscriptionsPostgresRepository) DeleteSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return sr.change(id, types.EventDeleted, actor, false, expectedVersion, func(tx *sql.Tx, _ types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		return scanSubscription(tx.QueryRow(deleteSubscriptionQuery, id))
+	})
+}
+
+func (sr SubscriptionsPostgresRepository) RestoreSubscription(id int, actor string) (types.SubscriptionResponse, error) {
+	query := `
+		UPDATE subscriptions
+		SET DeletedAt=NULL, Version=Version+1
+		WHERE id=$1
+		RETURNING ` + subscriptionColumns
+
+	return sr.change(id, types.EventRestored, actor, true, nil, func(tx *sql.Tx, _ types.SubscriptionResponse) (types.SubscriptionResponse, error) {
+		return scanSubscription(tx.QueryRow(query, id))
+	})
+}
+
+// ExportSubscriptions passes every matching subscription to fn as it is read
+// from the database, so the result is never held in memory as a whole.
+func (sr SubscriptionsPostgresRepository) ExportSubscriptions(filter types.SubscriptionsFilter, sort []types.SortField, fn func(types.SubscriptionResponse) error) error {
+	var qb queryBuilder
+	qb.filterSubscriptions(filter)
+
+	query := `
+		SELECT ` + subscriptionColumns + `
+		FROM subscriptions` + qb.whereSQL() + orderBySQL(sort)
+
+	rows, err := sr.db.Query(query, qb.args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		sub, err := scanSubscription(rows)
+		if err != nil {
+			return err
+		}
+
+		if err := fn(sub); err != nil {
+			return err
+		}
 	}
 
-	return result, nil
+	return rows.Err()
 }
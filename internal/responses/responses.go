@@ -3,6 +3,8 @@ package responses
 import (
 	"encoding/json"
 	"net/http"
+
+	"subscriptions-api/internal/apperrors"
 )
 
 func SetJsonBody(w http.ResponseWriter, v any) error {
@@ -20,3 +22,59 @@ func SetJsonBody(w http.ResponseWriter, v any) error {
 
 	return nil
 }
+
+// Problem is an RFC 7807 problem details body extended with a machine-readable
+// code and field-level errors.
+type Problem struct {
+	Type   string                 `json:"type" example:"about:blank"`
+	Title  string                 `json:"title" example:"Bad Request"`
+	Status int                    `json:"status" example:"400"`
+	Detail string                 `json:"detail,omitempty" example:"Request validation failed"`
+	Code   string                 `json:"code" example:"validation_failed"`
+	Errors []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+var kindStatuses = map[apperrors.Kind]int{
+	apperrors.KindInvalid:              http.StatusBadRequest,
+	apperrors.KindNotFound:             http.StatusNotFound,
+	apperrors.KindUnsupportedMediaType: http.StatusUnsupportedMediaType,
+	apperrors.KindPreconditionFailed:   http.StatusPreconditionFailed,
+	apperrors.KindConflict:             http.StatusConflict,
+}
+
+func SetProblem(w http.ResponseWriter, p Problem) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/problem+json")
+	w.WriteHeader(p.Status)
+	_, err = w.Write(data)
+
+	return err
+}
+
+// SetError writes err as a problem. Errors that are not application errors
+// are reported as a generic internal error without leaking their text.
+func SetError(w http.ResponseWriter, err error) {
+	p := Problem{
+		Type:   "about:blank",
+		Status: http.StatusInternalServerError,
+		Code:   "internal_error",
+		Detail: "Internal server error",
+	}
+
+	if appErr, ok := apperrors.As(err); ok {
+		if status, ok := kindStatuses[appErr.Kind]; ok {
+			p.Status = status
+			p.Code = appErr.Code
+			p.Detail = appErr.Message
+			p.Errors = appErr.Fields
+		}
+	}
+
+	p.Title = http.StatusText(p.Status)
+
+	SetProblem(w, p)
+}
@@ -0,0 +1,32 @@
+package types
+
+import "subscriptions-api/internal/apperrors"
+
+// SubscriptionUpdateItem is a full update of one subscription in a batch.
+// Version, when set, must match the stored version like If-Match does.
+type SubscriptionUpdateItem struct {
+	ID      int  `json:"id" validate:"required" example:"1"`
+	Version *int `json:"version,omitempty" example:"3"`
+	SubscriptionRequest
+}
+
+type BatchItemError struct {
+	Code    string                 `json:"code" example:"validation_failed"`
+	Message string                 `json:"message" example:"Request validation failed"`
+	Errors  []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// BatchItemResult is the outcome of one batch item, Index is its position in the request.
+type BatchItemResult struct {
+	Index        int                   `json:"index" example:"0"`
+	ID           int                   `json:"id,omitempty" example:"1"`
+	Subscription *SubscriptionResponse `json:"subscription,omitempty"`
+	Error        *BatchItemError       `json:"error,omitempty"`
+}
+
+// BatchResponse reports a batch executed in one transaction. When any item
+// fails nothing is committed and only the failed items are listed.
+type BatchResponse struct {
+	Committed bool              `json:"committed" example:"true"`
+	Items     []BatchItemResult `json:"items"`
+}
@@ -0,0 +1,42 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+	"subscriptions-api/internal/apperrors"
+	"time"
+)
+
+const (
+	BillingWeekly    = "weekly"
+	BillingMonthly   = "monthly"
+	BillingQuarterly = "quarterly"
+	BillingYearly    = "yearly"
+)
+
+func IsValidBillingPeriod(period string) bool {
+	switch period {
+	case BillingWeekly, BillingMonthly, BillingQuarterly, BillingYearly:
+		return true
+	}
+	return false
+}
+
+// Date is a calendar day in YYYY-MM-DD format.
+type Date time.Time
+
+func (d *Date) UnmarshalJSON(data []byte) error {
+	str := strings.Trim(string(data), `"`)
+
+	t, err := time.Parse(time.DateOnly, str)
+	if err != nil {
+		return &apperrors.Error{
+			Kind:    apperrors.KindInvalid,
+			Code:    apperrors.InvalidDate.Code,
+			Message: fmt.Sprintf("Date %q must be in YYYY-MM-DD format", str),
+		}
+	}
+
+	*d = Date(t)
+	return nil
+}
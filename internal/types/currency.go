@@ -0,0 +1,33 @@
+package types
+
+import "time"
+
+// BaseCurrency is the currency exchange rates are quoted in and the default
+// currency of subscriptions.
+const BaseCurrency = "RUB"
+
+// IsValidCurrency reports whether code looks like an ISO 4217 currency code.
+func IsValidCurrency(code string) bool {
+	if len(code) != 3 {
+		return false
+	}
+
+	for _, c := range code {
+		if c < 'A' || c > 'Z' {
+			return false
+		}
+	}
+
+	return true
+}
+
+// ExchangeRate is the price of one unit of Currency in BaseCurrency.
+type ExchangeRate struct {
+	Currency  string    `json:"currency" example:"USD"`
+	Rate      float64   `json:"rate" example:"92.5"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+type ExchangeRateRequest struct {
+	Rate float64 `json:"rate" validate:"required" example:"92.5"`
+}
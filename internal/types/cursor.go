@@ -0,0 +1,109 @@
+package types
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+// Cursor is the keyset position after which the next page of subscriptions starts.
+// It holds the sort it was issued for and the values of the last returned item
+// for every sort field. Clients receive it base64 encoded and must treat it as opaque.
+type Cursor struct {
+	Sort   string   `json:"s,omitempty"`
+	Values []string `json:"v,omitempty"`
+	ID     int      `json:"id"`
+}
+
+func NewCursor(sort []SortField, last SubscriptionResponse) Cursor {
+	values := make([]string, len(sort))
+	for i, f := range sort {
+		values[i] = sortValue(last, f.Field)
+	}
+
+	return Cursor{Sort: FormatSort(sort), Values: values, ID: last.ID}
+}
+
+// sortValue renders a sort field of sub the way Postgres parses it back.
+// Open-ended subscriptions sort as if they ended at infinity.
+func sortValue(sub SubscriptionResponse, field string) string {
+	switch field {
+	case SortByID:
+		return strconv.Itoa(sub.ID)
+	case SortByServiceName:
+		return sub.ServiceName
+	case SortByPrice:
+		return strconv.Itoa(sub.Price)
+	case SortByUserID:
+		return sub.UserID
+	case SortByStartDate:
+		return sub.StartDate.Format(time.DateOnly)
+	case SortByEndDate:
+		if sub.EndDate == nil {
+			return "infinity"
+		}
+		return sub.EndDate.Format(time.DateOnly)
+	}
+	return ""
+}
+
+// validSortValue reports whether value parses back as the sort field, so that
+// a tampered cursor is rejected before it reaches the database.
+func validSortValue(field, value string) bool {
+	switch field {
+	case SortByID, SortByPrice:
+		_, err := strconv.ParseInt(value, 10, 32)
+		return err == nil
+	case SortByServiceName:
+		return utf8.ValidString(value) && !strings.ContainsRune(value, 0)
+	case SortByUserID:
+		_, err := uuid.Parse(value)
+		return err == nil
+	case SortByEndDate:
+		if value == "infinity" {
+			return true
+		}
+		fallthrough
+	case SortByStartDate:
+		_, err := time.Parse(time.DateOnly, value)
+		return err == nil
+	}
+	return false
+}
+
+func (c Cursor) Encode() string {
+	data, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// DecodeCursor parses a cursor and checks that it was issued for the given sort
+// and that its values are valid for the sort fields.
+func DecodeCursor(s string, sort []SortField) (Cursor, error) {
+	data, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return Cursor{}, err
+	}
+
+	var c Cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return Cursor{}, err
+	}
+
+	if c.ID <= 0 || c.Sort != FormatSort(sort) || len(c.Values) != len(sort) {
+		return Cursor{}, errors.New("invalid cursor")
+	}
+
+	for i, f := range sort {
+		if !validSortValue(f.Field, c.Values[i]) {
+			return Cursor{}, errors.New("invalid cursor")
+		}
+	}
+
+	return c, nil
+}
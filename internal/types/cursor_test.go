@@ -0,0 +1,88 @@
+package types
+
+import (
+	"encoding/base64"
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestCursorRoundTrip(t *testing.T) {
+	end := time.Date(2025, 12, 1, 0, 0, 0, 0, time.UTC)
+	sub := SubscriptionResponse{
+		ID:          42,
+		ServiceName: "Netflix",
+		Price:       999,
+		UserID:      "60601fee-2bf1-4721-ae6f-7636e79a0cba",
+		StartDate:   time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+	ended := sub
+	ended.EndDate = &end
+
+	tests := []struct {
+		name string
+		sort []SortField
+		sub  SubscriptionResponse
+		want []string
+	}{
+		{name: "by id", sort: nil, sub: sub, want: []string{}},
+		{name: "by price", sort: []SortField{{Field: SortByPrice}}, sub: sub, want: []string{"999"}},
+		{
+			name: "by name and start date",
+			sort: []SortField{{Field: SortByServiceName}, {Field: SortByStartDate, Desc: true}},
+			sub:  sub,
+			want: []string{"Netflix", "2025-01-01"},
+		},
+		{name: "open end", sort: []SortField{{Field: SortByEndDate}}, sub: sub, want: []string{"infinity"}},
+		{name: "end", sort: []SortField{{Field: SortByEndDate, Desc: true}}, sub: ended, want: []string{"2025-12-01"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := DecodeCursor(NewCursor(tt.sort, tt.sub).Encode(), tt.sort)
+			if err != nil {
+				t.Fatalf("DecodeCursor unexpected error: %v", err)
+			}
+
+			if got.ID != tt.sub.ID || got.Sort != FormatSort(tt.sort) || !slices.Equal(got.Values, tt.want) {
+				t.Errorf("DecodeCursor = %+v, want id %d, sort %q, values %q", got, tt.sub.ID, FormatSort(tt.sort), tt.want)
+			}
+		})
+	}
+}
+
+func TestDecodeCursorRejectsTampering(t *testing.T) {
+	byPrice := []SortField{{Field: SortByPrice}}
+	encode := func(json string) string { return base64.RawURLEncoding.EncodeToString([]byte(json)) }
+
+	tests := []struct {
+		name   string
+		cursor string
+		sort   []SortField
+	}{
+		{name: "not base64", cursor: "not a cursor!", sort: nil},
+		{name: "padded base64", cursor: base64.URLEncoding.EncodeToString([]byte(`{"id":1}`)), sort: nil},
+		{name: "not json", cursor: encode(`id=1`), sort: nil},
+		{name: "wrong types", cursor: encode(`{"id":"1"}`), sort: nil},
+		{name: "missing id", cursor: encode(`{}`), sort: nil},
+		{name: "negative id", cursor: encode(`{"id":-1}`), sort: nil},
+		{name: "other sort", cursor: NewCursor(byPrice, SubscriptionResponse{ID: 1}).Encode(), sort: []SortField{{Field: SortByPrice, Desc: true}}},
+		{name: "sort dropped", cursor: NewCursor(byPrice, SubscriptionResponse{ID: 1}).Encode(), sort: nil},
+		{name: "missing values", cursor: encode(`{"s":"price","id":1}`), sort: byPrice},
+		{name: "extra values", cursor: encode(`{"s":"price","v":["1","2"],"id":1}`), sort: byPrice},
+		{name: "price not a number", cursor: encode(`{"s":"price","v":["abc"],"id":1}`), sort: byPrice},
+		{name: "price out of range", cursor: encode(`{"s":"price","v":["9999999999"],"id":1}`), sort: byPrice},
+		{name: "user id not a uuid", cursor: encode(`{"s":"user_id","v":["1"],"id":1}`), sort: []SortField{{Field: SortByUserID}}},
+		{name: "name with nul", cursor: encode(`{"s":"service_name","v":["a\u0000"],"id":1}`), sort: []SortField{{Field: SortByServiceName}}},
+		{name: "start date infinity", cursor: encode(`{"s":"start_date","v":["infinity"],"id":1}`), sort: []SortField{{Field: SortByStartDate}}},
+		{name: "end date not a date", cursor: encode(`{"s":"-end_date","v":["12-2025"],"id":1}`), sort: []SortField{{Field: SortByEndDate, Desc: true}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got, err := DecodeCursor(tt.cursor, tt.sort); err == nil {
+				t.Errorf("DecodeCursor(%q) = %+v, want an error", tt.cursor, got)
+			}
+		})
+	}
+}
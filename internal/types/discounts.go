@@ -0,0 +1,38 @@
+package types
+
+import "time"
+
+const (
+	DiscountTrial   = "trial"
+	DiscountPercent = "percent"
+	DiscountFixed   = "fixed"
+)
+
+func IsValidDiscountType(t string) bool {
+	switch t {
+	case DiscountTrial, DiscountPercent, DiscountFixed:
+		return true
+	}
+	return false
+}
+
+// DiscountRequest schedules a trial or a discount for Months months from
+// StartMonth, the subscription start when omitted. Value is the percent taken
+// off for a percent discount, the amount taken off each billing event in the
+// currency of the charge for a fixed one, and is omitted for a trial.
+type DiscountRequest struct {
+	Type       string     `json:"type" validate:"required" enums:"trial,percent,fixed" example:"percent"`
+	Value      int        `json:"value,omitempty" example:"50"`
+	StartMonth *MonthYear `json:"start_month,omitempty" swaggertype:"string" example:"01-2025"`
+	Months     int        `json:"months" validate:"required" minimum:"1" example:"3"`
+}
+
+// Discount is a trial or a discount applied to the charges of the months
+// [StartMonth, StartMonth + Months).
+type Discount struct {
+	ID         int       `json:"id" example:"1"`
+	Type       string    `json:"type" example:"percent"`
+	Value      int       `json:"value" example:"50"`
+	StartMonth time.Time `json:"start_month"`
+	Months     int       `json:"months" example:"3"`
+}
@@ -0,0 +1,31 @@
+package types
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	EventCreated         = "created"
+	EventUpdated         = "updated"
+	EventDeleted         = "deleted"
+	EventRestored        = "restored"
+	EventPriceChanged    = "price_changed"
+	EventDiscountAdded   = "discount_added"
+	EventDiscountRemoved = "discount_removed"
+	EventPaused          = "paused"
+	EventResumed         = "resumed"
+	EventCancelled       = "cancelled"
+)
+
+// SubscriptionEvent is an audit log entry with subscription snapshots taken
+// before and after the change. Before is null for created subscriptions.
+type SubscriptionEvent struct {
+	ID             int64           `json:"id" example:"1"`
+	SubscriptionID int             `json:"subscription_id" example:"42"`
+	Action         string          `json:"action" enums:"created,updated,deleted,restored,price_changed,discount_added,discount_removed,paused,resumed,cancelled" example:"updated"`
+	Actor          string          `json:"actor,omitempty" example:"billing-admin"`
+	Before         json.RawMessage `json:"before" swaggertype:"object"`
+	After          json.RawMessage `json:"after" swaggertype:"object"`
+	CreatedAt      time.Time       `json:"created_at"`
+}
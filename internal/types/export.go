@@ -0,0 +1,14 @@
+package types
+
+const (
+	ExportCSV    = "csv"
+	ExportNDJSON = "ndjson"
+)
+
+func IsValidExportFormat(format string) bool {
+	switch format {
+	case ExportCSV, ExportNDJSON:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,37 @@
+package types
+
+import "subscriptions-api/internal/apperrors"
+
+// ImportColumns are the CSV columns of a subscriptions import. The first
+// RequiredImportColumns of them are required, end_date, currency and the billing
+// columns may be empty and price may be empty when the service has a default price.
+var ImportColumns = []string{"service_name", "user_id", "start_date", "price", "end_date", "currency", "billing_period", "billing_anchor"}
+
+const RequiredImportColumns = 3
+
+// ImportRow is a subscription read from an import file. Errors holds the
+// problems of the values that could not be parsed. A Malformed row could not
+// be read at all and has no subscription, Errors tells why.
+type ImportRow struct {
+	Line         int
+	Subscription SubscriptionRequest
+	Errors       []apperrors.FieldError
+	Malformed    bool
+}
+
+// ImportRowResult reports one row of the file, Line is its line number.
+type ImportRowResult struct {
+	Line   int                    `json:"line" example:"2"`
+	ID     int                    `json:"id,omitempty" example:"1"`
+	Errors []apperrors.FieldError `json:"errors,omitempty"`
+}
+
+// ImportResponse reports an import. Rows are created only when all of them
+// are valid and it is not a dry run.
+type ImportResponse struct {
+	DryRun    bool              `json:"dry_run" example:"false"`
+	Committed bool              `json:"committed" example:"true"`
+	Total     int               `json:"total" example:"2"`
+	Invalid   int               `json:"invalid" example:"0"`
+	Rows      []ImportRowResult `json:"rows"`
+}
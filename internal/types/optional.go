@@ -0,0 +1,27 @@
+package types
+
+import "encoding/json"
+
+// Optional tracks whether a field was present in a JSON document, which
+// distinguishes an omitted field from an explicit null.
+type Optional[T any] struct {
+	Set   bool
+	Value *T
+}
+
+func (o *Optional[T]) UnmarshalJSON(data []byte) error {
+	o.Set = true
+
+	if string(data) == "null" {
+		o.Value = nil
+		return nil
+	}
+
+	var v T
+	if err := json.Unmarshal(data, &v); err != nil {
+		return err
+	}
+
+	o.Value = &v
+	return nil
+}
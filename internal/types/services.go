@@ -0,0 +1,19 @@
+package types
+
+import "time"
+
+// Service is a catalog entry subscriptions refer to. Subscriptions to a name
+// or an alias of it are all counted under Name.
+type Service struct {
+	ID           int       `json:"id" example:"1"`
+	Name         string    `json:"name" example:"Netflix"`
+	Aliases      []string  `json:"aliases" example:"netflix premium"`
+	DefaultPrice *int      `json:"default_price" example:"999"`
+	CreatedAt    time.Time `json:"created_at"`
+}
+
+type ServiceRequest struct {
+	Name         string   `json:"name" validate:"required" maxLength:"255" example:"Netflix"`
+	Aliases      []string `json:"aliases" example:"netflix premium"`
+	DefaultPrice *int     `json:"default_price,omitempty" minimum:"0" maximum:"10000000" example:"999"`
+}
@@ -0,0 +1,65 @@
+package types
+
+import (
+	"fmt"
+	"strings"
+)
+
+const (
+	SortByID          = "id"
+	SortByServiceName = "service_name"
+	SortByPrice       = "price"
+	SortByUserID      = "user_id"
+	SortByStartDate   = "start_date"
+	SortByEndDate     = "end_date"
+)
+
+type SortField struct {
+	Field string
+	Desc  bool
+}
+
+// ParseSort parses a sort query like "price,-start_date", where a leading minus means descending order.
+func ParseSort(s string) ([]SortField, error) {
+	if len(s) == 0 {
+		return nil, nil
+	}
+
+	parts := strings.Split(s, ",")
+	sort := make([]SortField, 0, len(parts))
+	seen := make(map[string]bool, len(parts))
+
+	for _, part := range parts {
+		f := SortField{Field: strings.TrimSpace(part)}
+		if strings.HasPrefix(f.Field, "-") {
+			f.Field, f.Desc = f.Field[1:], true
+		}
+
+		switch f.Field {
+		case SortByID, SortByServiceName, SortByPrice, SortByUserID, SortByStartDate, SortByEndDate:
+		default:
+			return nil, fmt.Errorf("unknown sort field %q", f.Field)
+		}
+
+		if seen[f.Field] {
+			return nil, fmt.Errorf("duplicate sort field %q", f.Field)
+		}
+		seen[f.Field] = true
+
+		sort = append(sort, f)
+	}
+
+	return sort, nil
+}
+
+func FormatSort(sort []SortField) string {
+	parts := make([]string, len(sort))
+	for i, f := range sort {
+		if f.Desc {
+			parts[i] = "-" + f.Field
+		} else {
+			parts[i] = f.Field
+		}
+	}
+	return strings.Join(parts, ",")
+}
@@ -0,0 +1,66 @@
+package types
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseSort(t *testing.T) {
+	tests := []struct {
+		query   string
+		want    []SortField
+		wantErr bool
+	}{
+		{query: "", want: nil},
+		{query: "price", want: []SortField{{Field: SortByPrice}}},
+		{query: "-start_date", want: []SortField{{Field: SortByStartDate, Desc: true}}},
+		{
+			query: "service_name, -end_date,id",
+			want:  []SortField{{Field: SortByServiceName}, {Field: SortByEndDate, Desc: true}, {Field: SortByID}},
+		},
+		{query: "cost", wantErr: true},
+		{query: "Price", wantErr: true},
+		{query: "+price", wantErr: true},
+		{query: "--price", wantErr: true},
+		{query: "- price", wantErr: true},
+		{query: "price,", wantErr: true},
+		{query: ",price", wantErr: true},
+		{query: "-", wantErr: true},
+		{query: "price,price", wantErr: true},
+		{query: "price,-price", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.query, func(t *testing.T) {
+			got, err := ParseSort(tt.query)
+
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseSort(%q) = %v, want an error", tt.query, got)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseSort(%q) unexpected error: %v", tt.query, err)
+			}
+
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("ParseSort(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatSortRoundTrip(t *testing.T) {
+	for _, query := range []string{"", "id", "-price", "user_id,-start_date,end_date"} {
+		sort, err := ParseSort(query)
+		if err != nil {
+			t.Fatalf("ParseSort(%q) unexpected error: %v", query, err)
+		}
+
+		if got := FormatSort(sort); got != query {
+			t.Errorf("FormatSort(ParseSort(%q)) = %q", query, got)
+		}
+	}
+}
@@ -0,0 +1,24 @@
+package types
+
+import "time"
+
+const (
+	StatusActive    = "active"
+	StatusPaused    = "paused"
+	StatusCancelled = "cancelled"
+)
+
+func IsValidStatus(status string) bool {
+	switch status {
+	case StatusActive, StatusPaused, StatusCancelled:
+		return true
+	}
+	return false
+}
+
+// PausePeriod is a pause of a subscription, its months from StartMonth until
+// EndMonth, exclusive, are not charged. EndMonth is null while it is paused.
+type PausePeriod struct {
+	StartMonth time.Time  `json:"start_month"`
+	EndMonth   *time.Time `json:"end_month"`
+}
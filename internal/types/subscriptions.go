@@ -1,7 +1,9 @@
 package types
 
 import (
+	"fmt"
 	"strings"
+	"subscriptions-api/internal/apperrors"
 	"time"
 
 	"github.com/google/uuid"
@@ -14,28 +16,297 @@ func (m *MonthYear) UnmarshalJSON(data []byte) error {
 
 	t, err := time.Parse("01-2006", str)
 	if err != nil {
-		return err
+		return &apperrors.Error{
+			Kind:    apperrors.KindInvalid,
+			Code:    apperrors.InvalidDate.Code,
+			Message: fmt.Sprintf("Date %q must be in MM-YYYY format", str),
+		}
 	}
 
 	*m = MonthYear(t)
 	return nil
 }
 
+func (m MonthYear) String() string {
+	return time.Time(m).Format("01-2006")
+}
+
+// AddMonths returns the month n months after m, or before it for a negative n.
+func (m MonthYear) AddMonths(n int) MonthYear {
+	return MonthYear(time.Time(m).AddDate(0, n, 0))
+}
+
+// CurrentMonth returns the first day of the current month.
+func CurrentMonth() time.Time {
+	now := time.Now()
+	return time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+}
+
+// SubscriptionRequest refers to a service either by ServiceID or by ServiceName,
+// which is resolved through the service names and aliases. Price may be omitted
+// when the service has a default price.
 type SubscriptionRequest struct {
-	ServiceName string    `json:"service_name" example:"Netflix"`
-	Price       int       `json:"price" example:"999"`
-	UserID      uuid.UUID `json:"user_id" format:"uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
-	StartDate   MonthYear `json:"start_date" swaggertype:"string" example:"01-2025"`
+	ServiceID   *int       `json:"service_id,omitempty" example:"1"`
+	ServiceName string     `json:"service_name,omitempty" maxLength:"255" example:"Netflix"`
+	Price       *int       `json:"price,omitempty" minimum:"0" maximum:"10000000" example:"999"`
+	Currency    string     `json:"currency,omitempty" example:"RUB"`
+	UserID      uuid.UUID  `json:"user_id" validate:"required" format:"uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate   MonthYear  `json:"start_date" validate:"required" swaggertype:"string" example:"01-2025"`
+	EndDate     *MonthYear `json:"end_date,omitempty" swaggertype:"string" example:"12-2025"`
+	// BillingPeriod is monthly when omitted, Price is charged once per period.
+	BillingPeriod string `json:"billing_period,omitempty" enums:"weekly,monthly,quarterly,yearly" example:"monthly"`
+	// BillingAnchor is the day of the first charge, the first day of StartDate when omitted.
+	BillingAnchor *Date `json:"billing_anchor,omitempty" swaggertype:"string" example:"2025-01-15"`
+}
+
+// EndsBeforeStart reports whether the optional end date precedes the start date.
+func (s SubscriptionRequest) EndsBeforeStart() bool {
+	return s.EndDate != nil && time.Time(*s.EndDate).Before(time.Time(s.StartDate))
 }
 
 type SubscriptionResponse struct {
-	ID          int       `json:"id"`
-	ServiceName string    `json:"service_name"`
-	Price       int       `json:"price"`
-	UserID      string    `json:"user_id"`
-	StartDate   time.Time `json:"start_date"`
+	ID          int        `json:"id"`
+	ServiceID   int        `json:"service_id" example:"1"`
+	ServiceName string     `json:"service_name"`
+	Price       int        `json:"price"`
+	Currency    string     `json:"currency" example:"RUB"`
+	UserID      string     `json:"user_id"`
+	StartDate   time.Time  `json:"start_date"`
+	EndDate     *time.Time `json:"end_date"`
+	// BillingPeriod is how often Price is charged, starting from BillingAnchor
+	// or from StartDate when the anchor is null.
+	BillingPeriod string     `json:"billing_period" example:"monthly"`
+	BillingAnchor *time.Time `json:"billing_anchor"`
+	// Status changes only through the pause, resume and cancel transitions.
+	Status    string     `json:"status" enums:"active,paused,cancelled" example:"active"`
+	Version   int        `json:"version" example:"1"`
+	DeletedAt *time.Time `json:"deleted_at,omitempty"`
 }
 
 type TotalStatsResponse struct {
-	Total int `json:"total"`
+	Total    int    `json:"total"`
+	Currency string `json:"currency" example:"RUB"`
+}
+
+// UserTotalResponse is what a user pays: the total billed over the requested period
+// and the spend and active subscriptions of the current month. MonthlySpend
+// normalizes every billing period to a month, a yearly price counts as a twelfth.
+type UserTotalResponse struct {
+	UserID       string `json:"user_id" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	Total        int    `json:"total" example:"11988"`
+	MonthlySpend int    `json:"monthly_spend" example:"999"`
+	ActiveCount  int    `json:"active_count" example:"1"`
+	Currency     string `json:"currency" example:"RUB"`
+}
+
+// DeletedFilter selects how soft-deleted subscriptions are treated by a query.
+type DeletedFilter int
+
+const (
+	ExcludeDeleted DeletedFilter = iota
+	IncludeDeleted
+	OnlyDeleted
+)
+
+type SubscriptionsFilter struct {
+	Deleted           DeletedFilter
+	UserID            string
+	ServiceName       string
+	ServiceNamePrefix string
+	PriceMin          *int
+	PriceMax          *int
+	StartFrom         *time.Time
+	StartTo           *time.Time
+	// ActiveAt selects subscriptions started on or before the month, not ended
+	// before it and not paused in it.
+	ActiveAt *time.Time
+	Status   string
+}
+
+type StatsFilter struct {
+	ServiceName string
+	UserID      string
+	StartDate   *time.Time
+	EndDate     *time.Time
+	// Currency the amounts are converted to, they are summed as is when empty.
+	Currency string
+}
+
+const (
+	GroupByServiceName = "service_name"
+	GroupByUserID      = "user_id"
+	GroupByMonth       = "month"
+)
+
+func IsValidGroupBy(groupBy string) bool {
+	switch groupBy {
+	case GroupByServiceName, GroupByUserID, GroupByMonth:
+		return true
+	}
+	return false
+}
+
+type BreakdownItem struct {
+	Key   string `json:"key" example:"Netflix"`
+	Total int    `json:"total" example:"5994"`
+	Count int    `json:"count" example:"1"`
+}
+
+type BreakdownResponse struct {
+	GroupBy  string          `json:"group_by" example:"service_name"`
+	Currency string          `json:"currency" example:"RUB"`
+	Items    []BreakdownItem `json:"items"`
+}
+
+// TimeSeriesPoint is the amount billed and the number of active subscriptions in
+// a month, paused subscriptions not being active.
+type TimeSeriesPoint struct {
+	Month       string `json:"month" example:"03-2025"`
+	Total       int    `json:"total" example:"1998"`
+	ActiveCount int    `json:"active_count" example:"2"`
+}
+
+type TimeSeriesResponse struct {
+	From     string            `json:"from" example:"01-2025"`
+	To       string            `json:"to" example:"12-2025"`
+	Currency string            `json:"currency" example:"RUB"`
+	Points   []TimeSeriesPoint `json:"points"`
+}
+
+// ForecastPoint is the amount to be billed in a month, the number of renewals
+// billed in it and the number of subscriptions active in it.
+type ForecastPoint struct {
+	Month       string `json:"month" example:"07-2025"`
+	Total       int    `json:"total" example:"1998"`
+	Renewals    int    `json:"renewals" example:"2"`
+	ActiveCount int    `json:"active_count" example:"2"`
+}
+
+// ForecastResponse projects the charges of the months [From, To], the first of
+// them being the current month.
+type ForecastResponse struct {
+	From     string          `json:"from" example:"06-2025"`
+	To       string          `json:"to" example:"05-2026"`
+	Currency string          `json:"currency" example:"RUB"`
+	Total    int             `json:"total" example:"11988"`
+	Points   []ForecastPoint `json:"points"`
+}
+
+type SubscriptionsPageResponse struct {
+	Items []SubscriptionResponse `json:"items"`
+	Total int                    `json:"total" example:"42"`
+	Page  int                    `json:"page" example:"2"`
+	Count int                    `json:"count" example:"10"`
+	Next  *string                `json:"next" example:"/subscriptions?count=10&page=3"`
+	Prev  *string                `json:"prev" example:"/subscriptions?count=10&page=1"`
+}
+
+type SubscriptionsCursorResponse struct {
+	Items      []SubscriptionResponse `json:"items"`
+	NextCursor string                 `json:"next_cursor,omitempty" example:"eyJpZCI6NDJ9"`
+}
+
+// SubscriptionPatch is a JSON Merge Patch (RFC 7396) of a subscription:
+// omitted fields are left unchanged and end_date is removed by an explicit null.
+type SubscriptionPatch struct {
+	ServiceID     Optional[int]       `json:"service_id" swaggertype:"integer" example:"1"`
+	ServiceName   Optional[string]    `json:"service_name" swaggertype:"string" example:"Netflix"`
+	Price         Optional[int]       `json:"price" swaggertype:"integer" example:"999"`
+	Currency      Optional[string]    `json:"currency" swaggertype:"string" example:"RUB"`
+	UserID        Optional[uuid.UUID] `json:"user_id" swaggertype:"string" format:"uuid" example:"60601fee-2bf1-4721-ae6f-7636e79a0cba"`
+	StartDate     Optional[MonthYear] `json:"start_date" swaggertype:"string" example:"01-2025"`
+	EndDate       Optional[MonthYear] `json:"end_date" swaggertype:"string" example:"12-2025"`
+	BillingPeriod Optional[string]    `json:"billing_period" swaggertype:"string" example:"yearly"`
+	// BillingAnchor is reset to StartDate with an explicit null.
+	BillingAnchor Optional[Date] `json:"billing_anchor" swaggertype:"string" example:"2025-01-15"`
+}
+
+// Apply returns sub with the fields supplied by the patch replaced.
+func (p SubscriptionPatch) Apply(sub SubscriptionRequest) SubscriptionRequest {
+	if p.ServiceID.Set && p.ServiceID.Value != nil {
+		sub.ServiceID = p.ServiceID.Value
+	}
+
+	// A new name is resolved again unless the patch sets the service id too.
+	if p.ServiceName.Set && p.ServiceName.Value != nil {
+		sub.ServiceName = *p.ServiceName.Value
+		if !p.ServiceID.Set {
+			sub.ServiceID = nil
+		}
+	}
+
+	if p.Price.Set && p.Price.Value != nil {
+		sub.Price = p.Price.Value
+	}
+
+	if p.Currency.Set && p.Currency.Value != nil {
+		sub.Currency = *p.Currency.Value
+	}
+
+	if p.UserID.Set && p.UserID.Value != nil {
+		sub.UserID = *p.UserID.Value
+	}
+
+	if p.StartDate.Set && p.StartDate.Value != nil {
+		sub.StartDate = *p.StartDate.Value
+	}
+
+	if p.EndDate.Set {
+		sub.EndDate = p.EndDate.Value
+	}
+
+	if p.BillingPeriod.Set && p.BillingPeriod.Value != nil {
+		sub.BillingPeriod = *p.BillingPeriod.Value
+	}
+
+	if p.BillingAnchor.Set {
+		sub.BillingAnchor = p.BillingAnchor.Value
+	}
+
+	return sub
+}
+
+// Request converts a stored subscription back into the request that would produce it.
+func (s SubscriptionResponse) Request() (SubscriptionRequest, error) {
+	userID, err := uuid.Parse(s.UserID)
+	if err != nil {
+		return SubscriptionRequest{}, err
+	}
+
+	req := SubscriptionRequest{
+		ServiceID:     &s.ServiceID,
+		ServiceName:   s.ServiceName,
+		Price:         &s.Price,
+		Currency:      s.Currency,
+		UserID:        userID,
+		StartDate:     MonthYear(s.StartDate),
+		BillingPeriod: s.BillingPeriod,
+	}
+
+	if s.EndDate != nil {
+		endDate := MonthYear(*s.EndDate)
+		req.EndDate = &endDate
+	}
+
+	if s.BillingAnchor != nil {
+		anchor := Date(*s.BillingAnchor)
+		req.BillingAnchor = &anchor
+	}
+
+	return req, nil
+}
+
+type PriceChangeRequest struct {
+	Price         int       `json:"price" validate:"required" minimum:"0" maximum:"10000000" example:"1199"`
+	EffectiveFrom MonthYear `json:"effective_from" validate:"required" swaggertype:"string" example:"06-2025"`
+}
+
+// PricePeriod is a subscription price in effect from a month until the next
+// period, with the billing terms it is charged on.
+type PricePeriod struct {
+	EffectiveFrom time.Time  `json:"effective_from"`
+	Price         int        `json:"price" example:"999"`
+	Currency      string     `json:"currency" example:"RUB"`
+	BillingPeriod string     `json:"billing_period" example:"monthly"`
+	BillingAnchor *time.Time `json:"billing_anchor"`
 }
@@ -0,0 +1,119 @@
+package types
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func TestSubscriptionPatchApply(t *testing.T) {
+	month := func(year int, m time.Month) *MonthYear {
+		v := MonthYear(time.Date(year, m, 1, 0, 0, 0, 0, time.UTC))
+		return &v
+	}
+	ptr := func(v int) *int { return &v }
+	anchor := Date(time.Date(2025, 1, 15, 0, 0, 0, 0, time.UTC))
+
+	base := SubscriptionRequest{
+		ServiceID:     ptr(1),
+		ServiceName:   "Netflix",
+		Price:         ptr(999),
+		Currency:      "RUB",
+		UserID:        uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:     *month(2025, time.January),
+		EndDate:       month(2025, time.December),
+		BillingPeriod: BillingMonthly,
+		BillingAnchor: &anchor,
+	}
+
+	tests := []struct {
+		name  string
+		patch string
+		want  func(sub *SubscriptionRequest)
+	}{
+		{name: "empty", patch: `{}`, want: func(*SubscriptionRequest) {}},
+		{name: "null end date removes it", patch: `{"end_date":null}`, want: func(sub *SubscriptionRequest) { sub.EndDate = nil }},
+		{
+			name:  "end date replaced",
+			patch: `{"end_date":"06-2026"}`,
+			want:  func(sub *SubscriptionRequest) { sub.EndDate = month(2026, time.June) },
+		},
+		{name: "null anchor resets it", patch: `{"billing_anchor":null}`, want: func(sub *SubscriptionRequest) { sub.BillingAnchor = nil }},
+		{name: "null price is ignored", patch: `{"price":null}`, want: func(*SubscriptionRequest) {}},
+		{name: "null start date is ignored", patch: `{"start_date":null}`, want: func(*SubscriptionRequest) {}},
+		{name: "null service name is ignored", patch: `{"service_name":null}`, want: func(*SubscriptionRequest) {}},
+		{name: "null billing period is ignored", patch: `{"billing_period":null}`, want: func(*SubscriptionRequest) {}},
+		{
+			name:  "new name is resolved again",
+			patch: `{"service_name":"Spotify"}`,
+			want: func(sub *SubscriptionRequest) {
+				sub.ServiceName = "Spotify"
+				sub.ServiceID = nil
+			},
+		},
+		{
+			name:  "new name with service id",
+			patch: `{"service_name":"Spotify","service_id":3}`,
+			want: func(sub *SubscriptionRequest) {
+				sub.ServiceName = "Spotify"
+				sub.ServiceID = ptr(3)
+			},
+		},
+		{
+			name:  "several fields",
+			patch: `{"price":0,"currency":"USD","billing_period":"yearly"}`,
+			want: func(sub *SubscriptionRequest) {
+				sub.Price = ptr(0)
+				sub.Currency = "USD"
+				sub.BillingPeriod = BillingYearly
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var patch SubscriptionPatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("unmarshal patch %s: %v", tt.patch, err)
+			}
+
+			want := base
+			tt.want(&want)
+
+			if got := patch.Apply(base); !reflect.DeepEqual(got, want) {
+				t.Errorf("Apply(%s) = %+v, want %+v", tt.patch, got, want)
+			}
+		})
+	}
+}
+
+func TestOptionalUnmarshal(t *testing.T) {
+	tests := []struct {
+		name    string
+		json    string
+		wantSet bool
+		want    *int
+	}{
+		{name: "omitted", json: `{}`, wantSet: false},
+		{name: "null", json: `{"v":null}`, wantSet: true},
+		{name: "value", json: `{"v":0}`, wantSet: true, want: new(int)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var doc struct {
+				V Optional[int] `json:"v"`
+			}
+			if err := json.Unmarshal([]byte(tt.json), &doc); err != nil {
+				t.Fatalf("unmarshal %s: %v", tt.json, err)
+			}
+
+			if doc.V.Set != tt.wantSet || !reflect.DeepEqual(doc.V.Value, tt.want) {
+				t.Errorf("unmarshal %s = %+v, want set %v and value %v", tt.json, doc.V, tt.wantSet, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,157 @@
+package usecases
+
+import (
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/validation"
+)
+
+var duplicateID = apperrors.Invalid(apperrors.FieldError{Field: "id", Code: "duplicate", Message: "must be unique in the batch"})
+
+func isAppError(err error) bool {
+	_, ok := apperrors.As(err)
+	return ok
+}
+
+func batchItemError(err error) *types.BatchItemError {
+	appErr, ok := apperrors.As(err)
+	if !ok {
+		return &types.BatchItemError{Code: "internal_error", Message: err.Error()}
+	}
+	return &types.BatchItemError{Code: appErr.Code, Message: appErr.Message, Errors: appErr.Fields}
+}
+
+// failedBatch reports the items with errors, or returns false when there are none.
+func failedBatch(ids []int, errs []error) (types.BatchResponse, bool) {
+	res := types.BatchResponse{Items: make([]types.BatchItemResult, 0)}
+
+	for i, err := range errs {
+		if err == nil {
+			continue
+		}
+
+		item := types.BatchItemResult{Index: i, Error: batchItemError(err)}
+		if ids != nil {
+			item.ID = ids[i]
+		}
+		res.Items = append(res.Items, item)
+	}
+
+	return res, len(res.Items) != 0
+}
+
+func committedBatch(subs []types.SubscriptionResponse) types.BatchResponse {
+	res := types.BatchResponse{Committed: true, Items: make([]types.BatchItemResult, len(subs))}
+
+	for i := range subs {
+		res.Items[i] = types.BatchItemResult{Index: i, ID: subs[i].ID, Subscription: &subs[i]}
+	}
+
+	return res
+}
+
+// checkBatchIDs validates the ids of a batch and reports duplicates as item errors.
+func checkBatchIDs(ids []int) []error {
+	errs := make([]error, len(ids))
+	seen := make(map[int]bool, len(ids))
+
+	for i, id := range ids {
+		switch {
+		case id <= 0:
+			errs[i] = apperrors.Invalid(apperrors.FieldError{Field: "id", Code: "invalid", Message: "must be a positive integer"})
+		case seen[id]:
+			errs[i] = duplicateID
+		}
+		seen[id] = true
+	}
+
+	return errs
+}
+
+// SaveSubscriptionsBatch creates all subscriptions or none of them.
+func (uc *SubscriptionUseCases) SaveSubscriptionsBatch(subs []types.SubscriptionRequest, actor string) (types.BatchResponse, error) {
+	if err := validation.ValidateBatchSize("items", len(subs)); err != nil {
+		return types.BatchResponse{}, err
+	}
+
+	errs := make([]error, len(subs))
+	for i := range subs {
+		if errs[i] = uc.prepareSubscription(&subs[i]); errs[i] != nil && !isAppError(errs[i]) {
+			return types.BatchResponse{}, errs[i]
+		}
+	}
+
+	if res, failed := failedBatch(nil, errs); failed {
+		return res, nil
+	}
+
+	created, errs, err := uc.repo.SaveSubscriptions(subs, actor)
+	if err != nil {
+		return types.BatchResponse{}, err
+	}
+
+	if res, failed := failedBatch(nil, errs); failed {
+		return res, nil
+	}
+
+	return committedBatch(created), nil
+}
+
+// UpdateSubscriptionsBatch replaces all subscriptions or none of them.
+func (uc *SubscriptionUseCases) UpdateSubscriptionsBatch(items []types.SubscriptionUpdateItem, actor string) (types.BatchResponse, error) {
+	if err := validation.ValidateBatchSize("items", len(items)); err != nil {
+		return types.BatchResponse{}, err
+	}
+
+	ids := make([]int, len(items))
+	for i := range items {
+		ids[i] = items[i].ID
+	}
+
+	errs := checkBatchIDs(ids)
+	for i := range items {
+		if errs[i] != nil {
+			continue
+		}
+		if errs[i] = uc.prepareSubscription(&items[i].SubscriptionRequest); errs[i] != nil && !isAppError(errs[i]) {
+			return types.BatchResponse{}, errs[i]
+		}
+	}
+
+	if res, failed := failedBatch(ids, errs); failed {
+		return res, nil
+	}
+
+	updated, errs, err := uc.repo.UpdateSubscriptions(items, actor)
+	if err != nil {
+		return types.BatchResponse{}, err
+	}
+
+	if res, failed := failedBatch(ids, errs); failed {
+		return res, nil
+	}
+
+	return committedBatch(updated), nil
+}
+
+// DeleteSubscriptionsBatch soft-deletes all subscriptions or none of them.
+func (uc *SubscriptionUseCases) DeleteSubscriptionsBatch(ids []int, actor string) (types.BatchResponse, error) {
+	if err := validation.ValidateBatchSize("ids", len(ids)); err != nil {
+		return types.BatchResponse{}, err
+	}
+
+	if res, failed := failedBatch(ids, checkBatchIDs(ids)); failed {
+		return res, nil
+	}
+
+	deleted, errs, err := uc.repo.DeleteSubscriptions(ids, actor)
+	if err != nil {
+		return types.BatchResponse{}, err
+	}
+
+	if res, failed := failedBatch(ids, errs); failed {
+		return res, nil
+	}
+
+	return committedBatch(deleted), nil
+}
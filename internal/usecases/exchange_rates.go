@@ -0,0 +1,50 @@
+package usecases
+
+import (
+	"strings"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/repositories"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/validation"
+)
+
+type ExchangeRateUseCases struct {
+	repo repositories.ExchangeRatesRepository
+}
+
+func NewExchangeRateUseCases(repo repositories.ExchangeRatesRepository) ExchangeRateUseCases {
+	return ExchangeRateUseCases{repo}
+}
+
+func (uc *ExchangeRateUseCases) GetExchangeRates() ([]types.ExchangeRate, error) {
+	return uc.repo.GetExchangeRates()
+}
+
+func (uc *ExchangeRateUseCases) GetExchangeRate(currency string) (types.ExchangeRate, error) {
+	return uc.repo.GetExchangeRate(strings.ToUpper(currency))
+}
+
+func (uc *ExchangeRateUseCases) SetExchangeRate(currency string, rate types.ExchangeRateRequest) (types.ExchangeRate, error) {
+	currency = strings.ToUpper(currency)
+
+	if err := validation.ValidateExchangeRate(currency, rate); err != nil {
+		return types.ExchangeRate{}, err
+	}
+
+	return uc.repo.SetExchangeRate(currency, rate.Rate)
+}
+
+// DeleteExchangeRate removes a rate, the rate of the base currency is kept.
+func (uc *ExchangeRateUseCases) DeleteExchangeRate(currency string) error {
+	currency = strings.ToUpper(currency)
+
+	if currency == types.BaseCurrency {
+		return apperrors.Invalid(apperrors.FieldError{
+			Field:   "currency",
+			Code:    "base_currency",
+			Message: "must not be the base currency " + types.BaseCurrency,
+		})
+	}
+
+	return uc.repo.DeleteExchangeRate(currency)
+}
@@ -0,0 +1,91 @@
+package usecases
+
+import (
+	"slices"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/repositories"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/validation"
+)
+
+var emptyImport = apperrors.Invalid(apperrors.FieldError{Field: "body", Code: "required", Message: "must have at least one row"})
+
+// importRowErrors validates a row like SaveSubscription does, looking its
+// service up with services. A value that could not be parsed is reported by
+// its parse error only.
+func importRowErrors(services serviceFinder, row *types.ImportRow) ([]apperrors.FieldError, error) {
+	if row.Malformed {
+		return row.Errors, nil
+	}
+
+	errs := row.Errors
+
+	normalizeSubscription(&row.Subscription)
+
+	err := validation.ValidateSubscription(row.Subscription)
+	if err == nil {
+		err = resolveService(services, &row.Subscription)
+	}
+
+	appErr, ok := apperrors.As(err)
+	if err != nil && !ok {
+		return nil, err
+	}
+
+	if ok {
+		for _, field := range appErr.Fields {
+			parsed := !slices.ContainsFunc(row.Errors, func(e apperrors.FieldError) bool { return e.Field == field.Field })
+			if parsed {
+				errs = append(errs, field)
+			}
+		}
+	}
+
+	return errs, nil
+}
+
+// ImportSubscriptions validates every row of an import file like
+// SaveSubscription does and creates them in one transaction, committed only
+// when all of them are valid and dryRun is not set. Services are looked up in
+// that transaction, the unknown ones are created with their subscriptions.
+func (uc *SubscriptionUseCases) ImportSubscriptions(rows []types.ImportRow, dryRun bool, actor string) (types.ImportResponse, error) {
+	if len(rows) == 0 {
+		return types.ImportResponse{}, emptyImport
+	}
+
+	res := types.ImportResponse{DryRun: dryRun, Total: len(rows), Rows: make([]types.ImportRowResult, len(rows))}
+
+	committed, err := uc.repo.ImportSubscriptions(actor, func(tx repositories.ImportTx) (bool, error) {
+		for i := range rows {
+			errs, err := importRowErrors(tx, &rows[i])
+			if err != nil {
+				return false, err
+			}
+
+			res.Rows[i] = types.ImportRowResult{Line: rows[i].Line, Errors: errs}
+			if len(errs) != 0 {
+				res.Invalid++
+			}
+		}
+
+		if dryRun || res.Invalid != 0 {
+			return false, nil
+		}
+
+		for i := range rows {
+			var err error
+			if res.Rows[i].ID, err = tx.Save(rows[i].Subscription); err != nil {
+				return false, err
+			}
+		}
+
+		return true, nil
+	})
+
+	if err != nil {
+		return types.ImportResponse{}, err
+	}
+
+	res.Committed = committed
+	return res, nil
+}
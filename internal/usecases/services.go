@@ -0,0 +1,65 @@
+package usecases
+
+import (
+	"slices"
+	"strings"
+	"subscriptions-api/internal/repositories"
+	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/validation"
+)
+
+type ServiceUseCases struct {
+	repo repositories.ServicesRepository
+}
+
+func NewServiceUseCases(repo repositories.ServicesRepository) ServiceUseCases {
+	return ServiceUseCases{repo}
+}
+
+// normalizeService trims the name and lower-cases the aliases, dropping
+// duplicates and the aliases equal to the name.
+func normalizeService(service *types.ServiceRequest) {
+	service.Name = strings.TrimSpace(service.Name)
+
+	aliases := make([]string, 0, len(service.Aliases))
+	for _, alias := range service.Aliases {
+		alias = strings.ToLower(strings.TrimSpace(alias))
+		if alias != strings.ToLower(service.Name) && !slices.Contains(aliases, alias) {
+			aliases = append(aliases, alias)
+		}
+	}
+	service.Aliases = aliases
+}
+
+func (uc *ServiceUseCases) GetServices() ([]types.Service, error) {
+	return uc.repo.GetServices()
+}
+
+func (uc *ServiceUseCases) GetService(id int) (types.Service, error) {
+	return uc.repo.GetService(id)
+}
+
+func (uc *ServiceUseCases) SaveService(service types.ServiceRequest) (types.Service, error) {
+	normalizeService(&service)
+
+	if err := validation.ValidateService(service); err != nil {
+		return types.Service{}, err
+	}
+
+	return uc.repo.SaveService(service)
+}
+
+// UpdateService replaces a service, renaming it renames its subscriptions.
+func (uc *ServiceUseCases) UpdateService(id int, service types.ServiceRequest, actor string) (types.Service, error) {
+	normalizeService(&service)
+
+	if err := validation.ValidateService(service); err != nil {
+		return types.Service{}, err
+	}
+
+	return uc.repo.UpdateService(id, service, actor)
+}
+
+func (uc *ServiceUseCases) DeleteService(id int) error {
+	return uc.repo.DeleteService(id)
+}
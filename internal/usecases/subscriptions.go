@@ -1,50 +1,443 @@
 package usecases
 
 import (
+	"errors"
+	"strings"
+	"subscriptions-api/internal/apperrors"
 	"subscriptions-api/internal/repositories"
 	"subscriptions-api/internal/types"
+	"subscriptions-api/internal/validation"
 	"time"
 )
 
 type SubscriptionUseCases struct {
-	repo repositories.SubscriptionsRepository
+	repo     repositories.SubscriptionsRepository
+	services repositories.ServicesRepository
 }
 
-func NewSubscriptionUseCases(repo repositories.SubscriptionsRepository) SubscriptionUseCases {
-	return SubscriptionUseCases{repo}
+func NewSubscriptionUseCases(repo repositories.SubscriptionsRepository, services repositories.ServicesRepository) SubscriptionUseCases {
+	return SubscriptionUseCases{repo, services}
 }
 
-func (uc *SubscriptionUseCases) SaveSubscription(sub types.SubscriptionRequest) (types.SubscriptionResponse, error) {
-	return uc.repo.SaveSubscription(sub)
+// normalizeSubscription trims the service name and defaults the currency to
+// the base one and the billing period to monthly.
+func normalizeSubscription(sub *types.SubscriptionRequest) {
+	sub.ServiceName = strings.TrimSpace(sub.ServiceName)
+	sub.Currency = normalizeCurrency(sub.Currency)
+	sub.BillingPeriod = normalizeBillingPeriod(sub.BillingPeriod)
+}
+
+func normalizeBillingPeriod(period string) string {
+	period = strings.ToLower(strings.TrimSpace(period))
+	if len(period) == 0 {
+		return types.BillingMonthly
+	}
+	return period
+}
+
+func normalizeCurrency(currency string) string {
+	currency = strings.ToUpper(strings.TrimSpace(currency))
+	if len(currency) == 0 {
+		return types.BaseCurrency
+	}
+	return currency
+}
+
+var priceRequired = apperrors.Invalid(apperrors.FieldError{
+	Field:   "price",
+	Code:    "required",
+	Message: "must be set when the service has no default price",
+})
+
+// serviceFinder looks services up, either through the services repository or
+// within the transaction of an import.
+type serviceFinder interface {
+	GetService(id int) (types.Service, error)
+	FindService(name string) (types.Service, error)
+}
+
+func (uc *SubscriptionUseCases) resolveService(sub *types.SubscriptionRequest) error {
+	return resolveService(uc.services, sub)
+}
+
+// resolveService points sub at its catalog service, found by id or by name and
+// aliases, and takes a missing price from the service default. Subscriptions
+// of unknown names are left unresolved for the repository to create their service.
+func resolveService(services serviceFinder, sub *types.SubscriptionRequest) error {
+	var service types.Service
+	var err error
+
+	switch {
+	case sub.ServiceID != nil:
+		service, err = services.GetService(*sub.ServiceID)
+		if errors.Is(err, apperrors.ServiceNotFound) {
+			return apperrors.Invalid(apperrors.FieldError{Field: "service_id", Code: "not_found", Message: "must be an id of a service"})
+		}
+	default:
+		service, err = services.FindService(sub.ServiceName)
+		if errors.Is(err, apperrors.ServiceNotFound) {
+			if sub.Price == nil {
+				return priceRequired
+			}
+			return nil
+		}
+	}
+
+	if err != nil {
+		return err
+	}
+
+	sub.ServiceID = &service.ID
+	sub.ServiceName = service.Name
+
+	if sub.Price == nil {
+		if service.DefaultPrice == nil {
+			return priceRequired
+		}
+		price := *service.DefaultPrice
+		sub.Price = &price
+	}
+
+	return nil
+}
+
+// prepareSubscription normalizes and validates sub and resolves its service
+// without creating one. The repository creates the service of an unknown name
+// in the transaction of the subscription write.
+func (uc *SubscriptionUseCases) prepareSubscription(sub *types.SubscriptionRequest) error {
+	normalizeSubscription(sub)
+
+	if err := validation.ValidateSubscription(*sub); err != nil {
+		return err
+	}
+
+	return uc.resolveService(sub)
+}
+
+// SaveSubscription creates a subscription, the service of an unknown name is
+// created with it.
+func (uc *SubscriptionUseCases) SaveSubscription(sub types.SubscriptionRequest, actor string) (types.SubscriptionResponse, error) {
+	if err := uc.prepareSubscription(&sub); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	return uc.repo.SaveSubscription(sub, actor)
 }
 
 func (uc *SubscriptionUseCases) GetSubscription(id int) (types.SubscriptionResponse, error) {
 	return uc.repo.GetSubscription(id)
 }
 
-func (uc *SubscriptionUseCases) GetSubscriptions(page int, count int) ([]types.SubscriptionResponse, error) {
-	return uc.repo.GetSubscriptions((page-1)*count, count)
+func (uc *SubscriptionUseCases) GetSubscriptions(filter types.SubscriptionsFilter, sort []types.SortField, page int, count int) (types.SubscriptionsPageResponse, error) {
+	if err := validation.ValidatePage(page, count); err != nil {
+		return types.SubscriptionsPageResponse{}, err
+	}
+
+	subs, err := uc.repo.GetSubscriptions(filter, sort, (page-1)*count, count)
+	if err != nil {
+		return types.SubscriptionsPageResponse{}, err
+	}
+
+	total, err := uc.repo.CountSubscriptions(filter)
+	if err != nil {
+		return types.SubscriptionsPageResponse{}, err
+	}
+
+	return types.SubscriptionsPageResponse{Items: subs, Total: total, Page: page, Count: count}, nil
 }
 
-func (uc *SubscriptionUseCases) DeleteSubscriptions(id int) (types.SubscriptionResponse, error) {
-	return uc.repo.DeleteSubscription(id)
+// GetSubscriptionsByCursor returns up to count subscriptions following cursor,
+// or from the beginning when cursor is nil.
+func (uc *SubscriptionUseCases) GetSubscriptionsByCursor(filter types.SubscriptionsFilter, sort []types.SortField, cursor *types.Cursor, count int) (types.SubscriptionsCursorResponse, error) {
+	if err := validation.ValidatePage(1, count); err != nil {
+		return types.SubscriptionsCursorResponse{}, err
+	}
+
+	// One extra row tells whether there is a next page.
+	subs, err := uc.repo.GetSubscriptionsAfter(filter, sort, cursor, count+1)
+	if err != nil {
+		return types.SubscriptionsCursorResponse{}, err
+	}
+
+	res := types.SubscriptionsCursorResponse{Items: subs}
+
+	if len(subs) > count {
+		res.Items = subs[:count]
+		res.NextCursor = types.NewCursor(sort, res.Items[count-1]).Encode()
+	}
+
+	return res, nil
 }
 
-func (uc *SubscriptionUseCases) UpdateSubscription(id int, subscription types.SubscriptionRequest) (types.SubscriptionResponse, error) {
-	return uc.repo.UpdateSubscription(id, subscription)
+// ExportSubscriptions streams all subscriptions matching filter to fn.
+func (uc *SubscriptionUseCases) ExportSubscriptions(filter types.SubscriptionsFilter, sort []types.SortField, fn func(types.SubscriptionResponse) error) error {
+	return uc.repo.ExportSubscriptions(filter, sort, fn)
 }
 
-func (uc *SubscriptionUseCases) GetTotalStats(serviceName, userID string, startDate, endDate *time.Time) (types.TotalStatsResponse, error) {
-	subs, err := uc.repo.GetSubscriptionsByFilter(serviceName, userID, startDate, endDate)
+func (uc *SubscriptionUseCases) DeleteSubscriptions(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return uc.repo.DeleteSubscription(id, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) RestoreSubscription(id int, actor string) (types.SubscriptionResponse, error) {
+	return uc.repo.RestoreSubscription(id, actor)
+}
+
+func (uc *SubscriptionUseCases) GetSubscriptionHistory(id int) ([]types.SubscriptionEvent, error) {
+	return uc.repo.GetSubscriptionHistory(id)
+}
+
+func (uc *SubscriptionUseCases) AddPriceChange(id int, change types.PriceChangeRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	sub, err := uc.repo.GetSubscription(id)
+	if err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	if err := validation.ValidatePriceChange(change, sub); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	return uc.repo.AddPriceChange(id, change, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) GetPriceHistory(id int) ([]types.PricePeriod, error) {
+	return uc.repo.GetPriceHistory(id)
+}
+
+func (uc *SubscriptionUseCases) PauseSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return uc.repo.PauseSubscription(id, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) ResumeSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return uc.repo.ResumeSubscription(id, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) CancelSubscription(id int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return uc.repo.CancelSubscription(id, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) GetPauses(id int) ([]types.PausePeriod, error) {
+	return uc.repo.GetPauses(id)
+}
+
+// AddDiscount schedules a trial or a discount starting at the subscription
+// start unless another start month is given.
+func (uc *SubscriptionUseCases) AddDiscount(id int, req types.DiscountRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, types.Discount, error) {
+	sub, err := uc.repo.GetSubscription(id)
+	if err != nil {
+		return types.SubscriptionResponse{}, types.Discount{}, err
+	}
+
+	req.Type = strings.ToLower(strings.TrimSpace(req.Type))
+
+	if req.StartMonth == nil {
+		startMonth := types.MonthYear(sub.StartDate)
+		req.StartMonth = &startMonth
+	}
+
+	if err := validation.ValidateDiscount(req, sub); err != nil {
+		return types.SubscriptionResponse{}, types.Discount{}, err
+	}
+
+	return uc.repo.AddDiscount(id, req, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) GetDiscounts(id int) ([]types.Discount, error) {
+	return uc.repo.GetDiscounts(id)
+}
+
+func (uc *SubscriptionUseCases) DeleteDiscount(id, discountID int, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	return uc.repo.DeleteDiscount(id, discountID, expectedVersion, actor)
+}
+
+func (uc *SubscriptionUseCases) UpdateSubscription(id int, subscription types.SubscriptionRequest, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	if err := uc.prepareSubscription(&subscription); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	return uc.repo.UpdateSubscription(id, subscription, expectedVersion, actor)
+}
+
+// PatchSubscription applies a merge patch, validating the resulting subscription
+// as a whole before only the supplied columns are written.
+func (uc *SubscriptionUseCases) PatchSubscription(id int, patch types.SubscriptionPatch, expectedVersion *int, actor string) (types.SubscriptionResponse, error) {
+	if err := validation.ValidateSubscriptionPatch(patch); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	if patch.ServiceName.Value != nil {
+		name := strings.TrimSpace(*patch.ServiceName.Value)
+		patch.ServiceName.Value = &name
+	}
+
+	if patch.Currency.Value != nil {
+		currency := strings.ToUpper(strings.TrimSpace(*patch.Currency.Value))
+		patch.Currency.Value = &currency
+	}
+
+	if patch.BillingPeriod.Value != nil {
+		period := strings.ToLower(strings.TrimSpace(*patch.BillingPeriod.Value))
+		patch.BillingPeriod.Value = &period
+	}
+
+	current, err := uc.repo.GetSubscription(id)
+	if err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	if expectedVersion != nil && current.Version != *expectedVersion {
+		return types.SubscriptionResponse{}, apperrors.VersionMismatch
+	}
+
+	req, err := current.Request()
+	if err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	patched := patch.Apply(req)
+
+	if err := validation.ValidateSubscription(patched); err != nil {
+		return types.SubscriptionResponse{}, err
+	}
+
+	// A changed service is resolved and both its id and name are written. The
+	// service of an unknown name is left to the repository to create.
+	if patch.ServiceID.Set || patch.ServiceName.Set {
+		if err := uc.resolveService(&patched); err != nil {
+			return types.SubscriptionResponse{}, err
+		}
+
+		patch.ServiceID = types.Optional[int]{Set: patched.ServiceID != nil, Value: patched.ServiceID}
+		patch.ServiceName = types.Optional[string]{Set: true, Value: &patched.ServiceName}
+	}
+
+	return uc.repo.PatchSubscription(id, patch, expectedVersion, actor)
+}
+
+// checkConvertible fails when some charges of filter cannot be converted to its currency.
+func (uc *SubscriptionUseCases) checkConvertible(filter types.StatsFilter) error {
+	if len(filter.Currency) == 0 {
+		return nil
+	}
+
+	currencies, err := uc.repo.GetUnconvertedCurrencies(filter)
+	if err != nil {
+		return err
+	}
+
+	if len(currencies) != 0 {
+		return apperrors.NoExchangeRate(filter.Currency, currencies)
+	}
+
+	return nil
+}
+
+func (uc *SubscriptionUseCases) GetTotalStats(filter types.StatsFilter) (types.TotalStatsResponse, error) {
+	if err := uc.checkConvertible(filter); err != nil {
+		return types.TotalStatsResponse{}, err
+	}
+
+	total, err := uc.repo.GetTotalCost(filter)
 
 	if err != nil {
 		return types.TotalStatsResponse{}, err
 	}
 
-	var total int
-	for _, sub := range subs {
-		total += sub.Price
+	return types.TotalStatsResponse{Total: total, Currency: filter.Currency}, nil
+}
+
+// GetUserTotal returns the user total over the filter period together with
+// the spend and the active subscriptions count of the current month.
+func (uc *SubscriptionUseCases) GetUserTotal(userID string, filter types.StatsFilter) (types.UserTotalResponse, error) {
+	filter.UserID = userID
+
+	month := types.CurrentMonth()
+	monthFilter := filter
+	monthFilter.StartDate, monthFilter.EndDate = &month, &month
+
+	for _, f := range []types.StatsFilter{filter, monthFilter} {
+		if err := uc.checkConvertible(f); err != nil {
+			return types.UserTotalResponse{}, err
+		}
+	}
+
+	total, err := uc.repo.GetTotalCost(filter)
+	if err != nil {
+		return types.UserTotalResponse{}, err
+	}
+
+	spend, active, err := uc.repo.GetChargesSummary(monthFilter)
+	if err != nil {
+		return types.UserTotalResponse{}, err
+	}
+
+	return types.UserTotalResponse{UserID: userID, Total: total, MonthlySpend: spend, ActiveCount: active, Currency: filter.Currency}, nil
+}
+
+func (uc *SubscriptionUseCases) GetCostBreakdown(groupBy string, filter types.StatsFilter) (types.BreakdownResponse, error) {
+	if err := uc.checkConvertible(filter); err != nil {
+		return types.BreakdownResponse{}, err
+	}
+
+	items, err := uc.repo.GetCostBreakdown(groupBy, filter)
+
+	if err != nil {
+		return types.BreakdownResponse{}, err
+	}
+
+	return types.BreakdownResponse{GroupBy: groupBy, Currency: filter.Currency, Items: items}, nil
+}
+
+// GetTimeSeries returns the monthly spend over [filter.StartDate, filter.EndDate].
+func (uc *SubscriptionUseCases) GetTimeSeries(filter types.StatsFilter) (types.TimeSeriesResponse, error) {
+	if err := validation.ValidateTimeSeries(*filter.StartDate, *filter.EndDate); err != nil {
+		return types.TimeSeriesResponse{}, err
+	}
+
+	if err := uc.checkConvertible(filter); err != nil {
+		return types.TimeSeriesResponse{}, err
+	}
+
+	points, err := uc.repo.GetTimeSeries(filter)
+	if err != nil {
+		return types.TimeSeriesResponse{}, err
+	}
+
+	return types.TimeSeriesResponse{
+		From:     types.MonthYear(*filter.StartDate).String(),
+		To:       types.MonthYear(*filter.EndDate).String(),
+		Currency: filter.Currency,
+		Points:   points,
+	}, nil
+}
+
+// GetForecast projects the charges of the next months, the current one
+// included, from the subscriptions active then: their billing periods, end
+// dates, scheduled prices and discounts are taken into account.
+func (uc *SubscriptionUseCases) GetForecast(months int, filter types.StatsFilter) (types.ForecastResponse, error) {
+	if err := validation.ValidateForecast(months); err != nil {
+		return types.ForecastResponse{}, err
+	}
+
+	from := types.MonthYear(types.CurrentMonth())
+	to := from.AddMonths(months - 1)
+
+	startDate, endDate := time.Time(from), time.Time(to)
+	filter.StartDate, filter.EndDate = &startDate, &endDate
+
+	if err := uc.checkConvertible(filter); err != nil {
+		return types.ForecastResponse{}, err
+	}
+
+	points, err := uc.repo.GetForecast(filter)
+	if err != nil {
+		return types.ForecastResponse{}, err
+	}
+
+	res := types.ForecastResponse{From: from.String(), To: to.String(), Currency: filter.Currency, Points: points}
+
+	for _, point := range points {
+		res.Total += point.Total
 	}
 
-	return types.TotalStatsResponse{Total: total}, nil
+	return res, nil
 }
@@ -0,0 +1,244 @@
+package validation
+
+import (
+	"fmt"
+	"math"
+	"strings"
+	"subscriptions-api/internal/types"
+	"time"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+const (
+	MaxServiceNameLength = 255
+	MaxPrice             = 10_000_000
+	MinYear              = 1970
+	MaxYear              = 2100
+)
+
+func validMonth(m types.MonthYear) bool {
+	year := time.Time(m).Year()
+	return year >= MinYear && year <= MaxYear
+}
+
+var monthRangeMessage = fmt.Sprintf("must be between %d and %d", MinYear, MaxYear)
+
+const currencyMessage = "must be an ISO 4217 currency code"
+
+var billingPeriodMessage = fmt.Sprintf(
+	"must be one of %s, %s, %s, %s",
+	types.BillingWeekly, types.BillingMonthly, types.BillingQuarterly, types.BillingYearly,
+)
+
+func checkPrice(v *Validator, field string, price int) {
+	v.Check(price >= 0, field, "negative", "must not be negative")
+	v.Check(price <= MaxPrice, field, "too_large", fmt.Sprintf("must be at most %d", MaxPrice))
+}
+
+func ValidateSubscription(req types.SubscriptionRequest) error {
+	var v Validator
+
+	name := strings.TrimSpace(req.ServiceName)
+	v.Check(req.ServiceID != nil || len(name) != 0, "service_name", "required", "must not be empty unless service_id is set")
+	v.Check(req.ServiceID == nil || *req.ServiceID > 0, "service_id", "invalid", "must be a positive integer")
+	v.Check(
+		utf8.RuneCountInString(name) <= MaxServiceNameLength,
+		"service_name", "too_long", fmt.Sprintf("must be at most %d characters", MaxServiceNameLength),
+	)
+
+	// A missing price is taken from the service default once the service is resolved.
+	if req.Price != nil {
+		checkPrice(&v, "price", *req.Price)
+	}
+
+	v.Check(types.IsValidCurrency(req.Currency), "currency", "invalid", currencyMessage)
+
+	v.Check(req.UserID != uuid.Nil, "user_id", "required", "must be a non-nil UUID")
+
+	if time.Time(req.StartDate).IsZero() {
+		v.Check(false, "start_date", "required", "must be set")
+	} else {
+		v.Check(validMonth(req.StartDate), "start_date", "out_of_range", monthRangeMessage)
+	}
+
+	if req.EndDate != nil {
+		v.Check(validMonth(*req.EndDate), "end_date", "out_of_range", monthRangeMessage)
+		v.Check(!req.EndsBeforeStart(), "end_date", "before_start", "must not be before start_date")
+	}
+
+	v.Check(types.IsValidBillingPeriod(req.BillingPeriod), "billing_period", "invalid", billingPeriodMessage)
+
+	if req.BillingAnchor != nil {
+		anchor := time.Time(*req.BillingAnchor)
+		v.Check(!anchor.Before(time.Time(req.StartDate)), "billing_anchor", "before_start", "must not be before start_date")
+		v.Check(
+			req.EndDate == nil || anchor.Before(time.Time(*req.EndDate).AddDate(0, 1, 0)),
+			"billing_anchor", "after_end", "must not be after end_date",
+		)
+	}
+
+	return v.Err()
+}
+
+// ValidateSubscriptionPatch rejects nulls for fields that cannot be removed.
+// The patched subscription as a whole is checked with ValidateSubscription.
+func ValidateSubscriptionPatch(patch types.SubscriptionPatch) error {
+	var v Validator
+
+	v.Check(!patch.ServiceID.Set || patch.ServiceID.Value != nil, "service_id", "required", "must not be null")
+	v.Check(!patch.ServiceName.Set || patch.ServiceName.Value != nil, "service_name", "required", "must not be null")
+	v.Check(!patch.Price.Set || patch.Price.Value != nil, "price", "required", "must not be null")
+	v.Check(!patch.Currency.Set || patch.Currency.Value != nil, "currency", "required", "must not be null")
+	v.Check(!patch.UserID.Set || patch.UserID.Value != nil, "user_id", "required", "must not be null")
+	v.Check(!patch.StartDate.Set || patch.StartDate.Value != nil, "start_date", "required", "must not be null")
+	v.Check(!patch.BillingPeriod.Set || patch.BillingPeriod.Value != nil, "billing_period", "required", "must not be null")
+
+	return v.Err()
+}
+
+// ValidatePriceChange checks a price change against the subscription it applies to:
+// the new price must be effective within the subscription active period.
+func ValidatePriceChange(change types.PriceChangeRequest, sub types.SubscriptionResponse) error {
+	var v Validator
+
+	checkPrice(&v, "price", change.Price)
+
+	effectiveFrom := time.Time(change.EffectiveFrom)
+
+	if effectiveFrom.IsZero() {
+		v.Check(false, "effective_from", "required", "must be set")
+	} else {
+		v.Check(!effectiveFrom.Before(sub.StartDate), "effective_from", "before_start", "must not be before start_date of the subscription")
+		v.Check(sub.EndDate == nil || !effectiveFrom.After(*sub.EndDate), "effective_from", "after_end", "must not be after end_date of the subscription")
+	}
+
+	return v.Err()
+}
+
+// MaxDiscountMonths limits the length of one trial or discount.
+const MaxDiscountMonths = 120
+
+// ValidateDiscount checks a discount against the subscription it applies to:
+// it must start within the subscription active period. StartMonth must be set.
+func ValidateDiscount(req types.DiscountRequest, sub types.SubscriptionResponse) error {
+	var v Validator
+
+	v.Check(types.IsValidDiscountType(req.Type), "type", "invalid", "must be one of trial, percent, fixed")
+	v.Check(req.Months > 0, "months", "required", "must be a positive integer")
+	v.Check(req.Months <= MaxDiscountMonths, "months", "too_large", fmt.Sprintf("must be at most %d", MaxDiscountMonths))
+
+	switch req.Type {
+	case types.DiscountTrial:
+		v.Check(req.Value == 0, "value", "not_allowed", "must be omitted for a trial")
+	case types.DiscountPercent:
+		v.Check(req.Value >= 1 && req.Value <= 100, "value", "out_of_range", "must be between 1 and 100")
+	case types.DiscountFixed:
+		v.Check(req.Value > 0, "value", "required", "must be a positive amount")
+		v.Check(req.Value <= MaxPrice, "value", "too_large", fmt.Sprintf("must be at most %d", MaxPrice))
+	}
+
+	startMonth := time.Time(*req.StartMonth)
+	v.Check(!startMonth.Before(sub.StartDate), "start_month", "before_start", "must not be before start_date of the subscription")
+	v.Check(sub.EndDate == nil || !startMonth.After(*sub.EndDate), "start_month", "after_end", "must not be after end_date of the subscription")
+
+	return v.Err()
+}
+
+// MaxBatchSize limits the number of items in one batch request.
+const MaxBatchSize = 1000
+
+func ValidateBatchSize(field string, n int) error {
+	var v Validator
+
+	v.Check(n > 0, field, "required", "must not be empty")
+	v.Check(n <= MaxBatchSize, field, "too_many", fmt.Sprintf("must have at most %d items", MaxBatchSize))
+
+	return v.Err()
+}
+
+// MaxPageSize limits the number of items in one page of a list.
+const MaxPageSize = 1000
+
+// ValidatePage checks the size of a page and that its offset fits an int.
+func ValidatePage(page, count int) error {
+	var v Validator
+
+	v.Check(count <= MaxPageSize, "count", "too_large", fmt.Sprintf("must be at most %d", MaxPageSize))
+	v.Check(page-1 <= math.MaxInt/count, "page", "too_large", "is past any possible page")
+
+	return v.Err()
+}
+
+// MaxImportRows limits the number of rows in one import file.
+const MaxImportRows = 10_000
+
+// MaxImportBytes limits the size of one import file.
+const MaxImportBytes = 4 << 20
+
+// MaxTimeSeriesMonths limits the number of points in one time series.
+const MaxTimeSeriesMonths = 240
+
+// ValidateTimeSeries checks that [from, to] is a month range of a reasonable length.
+func ValidateTimeSeries(from, to time.Time) error {
+	var v Validator
+
+	months := (to.Year()-from.Year())*12 + int(to.Month()-from.Month()) + 1
+	v.Check(months > 0, "to", "before_from", "must not be before from")
+	v.Check(months <= MaxTimeSeriesMonths, "to", "too_far", fmt.Sprintf("must be at most %d months after from", MaxTimeSeriesMonths-1))
+
+	return v.Err()
+}
+
+// MaxForecastMonths limits how far a forecast looks ahead.
+const MaxForecastMonths = 60
+
+func ValidateForecast(months int) error {
+	var v Validator
+
+	v.Check(months <= MaxForecastMonths, "months", "too_large", fmt.Sprintf("must be at most %d", MaxForecastMonths))
+
+	return v.Err()
+}
+
+// ValidateExchangeRate checks a rate of currency to the base currency, which
+// is always 1 and cannot be changed.
+func ValidateExchangeRate(currency string, rate types.ExchangeRateRequest) error {
+	var v Validator
+
+	v.Check(types.IsValidCurrency(currency), "currency", "invalid", currencyMessage)
+	v.Check(currency != types.BaseCurrency, "currency", "base_currency", "must not be the base currency "+types.BaseCurrency)
+	v.Check(rate.Rate > 0, "rate", "not_positive", "must be positive")
+
+	return v.Err()
+}
+
+const MaxServiceAliases = 50
+
+// ValidateService checks a normalized service, whose aliases are trimmed and lower-cased.
+func ValidateService(req types.ServiceRequest) error {
+	var v Validator
+
+	v.Check(len(req.Name) != 0, "name", "required", "must not be empty")
+	v.Check(
+		utf8.RuneCountInString(req.Name) <= MaxServiceNameLength,
+		"name", "too_long", fmt.Sprintf("must be at most %d characters", MaxServiceNameLength),
+	)
+
+	v.Check(len(req.Aliases) <= MaxServiceAliases, "aliases", "too_many", fmt.Sprintf("must have at most %d items", MaxServiceAliases))
+
+	for _, alias := range req.Aliases {
+		v.Check(len(alias) != 0, "aliases", "required", "must not contain empty aliases")
+		v.Check(
+			utf8.RuneCountInString(alias) <= MaxServiceNameLength,
+			"aliases", "too_long", fmt.Sprintf("must be at most %d characters each", MaxServiceNameLength),
+		)
+	}
+
+	if req.DefaultPrice != nil {
+		checkPrice(&v, "default_price", *req.DefaultPrice)
+	}
+
+	return v.Err()
+}
@@ -0,0 +1,251 @@
+package validation
+
+import (
+	"encoding/json"
+	"math"
+	"slices"
+	"strings"
+	"subscriptions-api/internal/apperrors"
+	"subscriptions-api/internal/types"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// violations returns the "field:code" pairs of a validation error, or nil
+// when err is nil.
+func violations(t *testing.T, err error) []string {
+	t.Helper()
+
+	if err == nil {
+		return nil
+	}
+
+	e, ok := apperrors.As(err)
+	if !ok || e.Kind != apperrors.KindInvalid {
+		t.Fatalf("want a validation error, got %v", err)
+	}
+
+	res := make([]string, len(e.Fields))
+	for i, f := range e.Fields {
+		res[i] = f.Field + ":" + f.Code
+	}
+	return res
+}
+
+func TestValidateSubscriptionPatch(t *testing.T) {
+	tests := []struct {
+		patch string
+		want  []string
+	}{
+		{patch: `{}`},
+		{patch: `{"end_date":null,"billing_anchor":null}`},
+		{patch: `{"price":0,"service_name":"Netflix"}`},
+		{patch: `{"service_id":null}`, want: []string{"service_id:required"}},
+		{patch: `{"service_name":null}`, want: []string{"service_name:required"}},
+		{patch: `{"price":null}`, want: []string{"price:required"}},
+		{patch: `{"currency":null}`, want: []string{"currency:required"}},
+		{patch: `{"user_id":null}`, want: []string{"user_id:required"}},
+		{patch: `{"start_date":null}`, want: []string{"start_date:required"}},
+		{patch: `{"billing_period":null}`, want: []string{"billing_period:required"}},
+		{
+			patch: `{"price":null,"start_date":null,"end_date":null}`,
+			want:  []string{"price:required", "start_date:required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.patch, func(t *testing.T) {
+			var patch types.SubscriptionPatch
+			if err := json.Unmarshal([]byte(tt.patch), &patch); err != nil {
+				t.Fatalf("unmarshal patch %s: %v", tt.patch, err)
+			}
+
+			if got := violations(t, ValidateSubscriptionPatch(patch)); !slices.Equal(got, tt.want) {
+				t.Errorf("ValidateSubscriptionPatch(%s) = %v, want %v", tt.patch, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateSubscription(t *testing.T) {
+	month := func(year int, m time.Month) types.MonthYear {
+		return types.MonthYear(time.Date(year, m, 1, 0, 0, 0, 0, time.UTC))
+	}
+	date := func(year int, m time.Month, day int) *types.Date {
+		d := types.Date(time.Date(year, m, day, 0, 0, 0, 0, time.UTC))
+		return &d
+	}
+	ptr := func(v int) *int { return &v }
+
+	valid := types.SubscriptionRequest{
+		ServiceName:   "Netflix",
+		Price:         ptr(999),
+		Currency:      "RUB",
+		UserID:        uuid.MustParse("60601fee-2bf1-4721-ae6f-7636e79a0cba"),
+		StartDate:     month(2025, time.January),
+		BillingPeriod: types.BillingMonthly,
+	}
+
+	tests := []struct {
+		name   string
+		change func(req *types.SubscriptionRequest)
+		want   []string
+	}{
+		{name: "valid", change: func(*types.SubscriptionRequest) {}},
+		{name: "service id without name", change: func(req *types.SubscriptionRequest) { req.ServiceID, req.ServiceName = ptr(1), "" }},
+		{name: "price taken from the service", change: func(req *types.SubscriptionRequest) { req.Price = nil }},
+		{name: "free", change: func(req *types.SubscriptionRequest) { req.Price = ptr(0) }},
+		{name: "max price", change: func(req *types.SubscriptionRequest) { req.Price = ptr(MaxPrice) }},
+		{name: "blank name", change: func(req *types.SubscriptionRequest) { req.ServiceName = "  " }, want: []string{"service_name:required"}},
+		{
+			name:   "long name",
+			change: func(req *types.SubscriptionRequest) { req.ServiceName = strings.Repeat("я", MaxServiceNameLength+1) },
+			want:   []string{"service_name:too_long"},
+		},
+		{name: "service id zero", change: func(req *types.SubscriptionRequest) { req.ServiceID = ptr(0) }, want: []string{"service_id:invalid"}},
+		{name: "negative price", change: func(req *types.SubscriptionRequest) { req.Price = ptr(-1) }, want: []string{"price:negative"}},
+		{name: "price too large", change: func(req *types.SubscriptionRequest) { req.Price = ptr(MaxPrice + 1) }, want: []string{"price:too_large"}},
+		{name: "malformed currency", change: func(req *types.SubscriptionRequest) { req.Currency = "rub" }, want: []string{"currency:invalid"}},
+		{name: "nil user", change: func(req *types.SubscriptionRequest) { req.UserID = uuid.Nil }, want: []string{"user_id:required"}},
+		{name: "no start", change: func(req *types.SubscriptionRequest) { req.StartDate = types.MonthYear{} }, want: []string{"start_date:required"}},
+		{
+			name:   "start out of range",
+			change: func(req *types.SubscriptionRequest) { req.StartDate = month(MinYear-1, time.December) },
+			want:   []string{"start_date:out_of_range"},
+		},
+		{
+			name: "end in start month",
+			change: func(req *types.SubscriptionRequest) {
+				end := month(2025, time.January)
+				req.EndDate = &end
+			},
+		},
+		{
+			name: "end before start",
+			change: func(req *types.SubscriptionRequest) {
+				end := month(2024, time.December)
+				req.EndDate = &end
+			},
+			want: []string{"end_date:before_start"},
+		},
+		{
+			name: "end out of range",
+			change: func(req *types.SubscriptionRequest) {
+				end := month(MaxYear+1, time.January)
+				req.EndDate = &end
+			},
+			want: []string{"end_date:out_of_range"},
+		},
+		{name: "unknown period", change: func(req *types.SubscriptionRequest) { req.BillingPeriod = "daily" }, want: []string{"billing_period:invalid"}},
+		{name: "anchor in start month", change: func(req *types.SubscriptionRequest) { req.BillingAnchor = date(2025, time.January, 31) }},
+		{
+			name:   "anchor before start",
+			change: func(req *types.SubscriptionRequest) { req.BillingAnchor = date(2024, time.December, 31) },
+			want:   []string{"billing_anchor:before_start"},
+		},
+		{
+			name: "anchor on last day of end month",
+			change: func(req *types.SubscriptionRequest) {
+				end := month(2025, time.February)
+				req.EndDate, req.BillingAnchor = &end, date(2025, time.February, 28)
+			},
+		},
+		{
+			name: "anchor after end",
+			change: func(req *types.SubscriptionRequest) {
+				end := month(2025, time.February)
+				req.EndDate, req.BillingAnchor = &end, date(2025, time.March, 1)
+			},
+			want: []string{"billing_anchor:after_end"},
+		},
+		{
+			name: "every violation reported",
+			change: func(req *types.SubscriptionRequest) {
+				req.ServiceName, req.Price, req.UserID = "", ptr(-1), uuid.Nil
+			},
+			want: []string{"service_name:required", "price:negative", "user_id:required"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := valid
+			tt.change(&req)
+
+			if got := violations(t, ValidateSubscription(req)); !slices.Equal(got, tt.want) {
+				t.Errorf("ValidateSubscription = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateTimeSeries(t *testing.T) {
+	month := func(year int, m time.Month) time.Time { return time.Date(year, m, 1, 0, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name     string
+		from, to time.Time
+		want     []string
+	}{
+		{name: "one month", from: month(2025, time.March), to: month(2025, time.March)},
+		{name: "over new year", from: month(2024, time.November), to: month(2025, time.February)},
+		{name: "longest", from: month(2025, time.January), to: month(2025, time.January).AddDate(0, MaxTimeSeriesMonths-1, 0)},
+		{name: "to before from", from: month(2025, time.March), to: month(2025, time.February), want: []string{"to:before_from"}},
+		{
+			name: "too long",
+			from: month(2025, time.January),
+			to:   month(2025, time.January).AddDate(0, MaxTimeSeriesMonths, 0),
+			want: []string{"to:too_far"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := violations(t, ValidateTimeSeries(tt.from, tt.to)); !slices.Equal(got, tt.want) {
+				t.Errorf("ValidateTimeSeries = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateBatchSize(t *testing.T) {
+	tests := []struct {
+		n    int
+		want []string
+	}{
+		{n: 1},
+		{n: MaxBatchSize},
+		{n: 0, want: []string{"items:required"}},
+		{n: MaxBatchSize + 1, want: []string{"items:too_many"}},
+	}
+
+	for _, tt := range tests {
+		if got := violations(t, ValidateBatchSize("items", tt.n)); !slices.Equal(got, tt.want) {
+			t.Errorf("ValidateBatchSize(%d) = %v, want %v", tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestValidatePage(t *testing.T) {
+	tests := []struct {
+		page, count int
+		want        []string
+	}{
+		{page: 1, count: 1},
+		{page: 1, count: MaxPageSize},
+		{page: 1_000_000, count: MaxPageSize},
+		{page: 1, count: MaxPageSize + 1, want: []string{"count:too_large"}},
+		{page: math.MaxInt, count: 2, want: []string{"page:too_large"}},
+		{page: math.MaxInt/MaxPageSize + 1, count: MaxPageSize},
+		{page: math.MaxInt/MaxPageSize + 2, count: MaxPageSize, want: []string{"page:too_large"}},
+		{page: math.MaxInt, count: 1},
+	}
+
+	for _, tt := range tests {
+		if got := violations(t, ValidatePage(tt.page, tt.count)); !slices.Equal(got, tt.want) {
+			t.Errorf("ValidatePage(%d, %d) = %v, want %v", tt.page, tt.count, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,23 @@
+package validation
+
+import "subscriptions-api/internal/apperrors"
+
+// Validator collects field violations so that all of them are reported at once.
+type Validator struct {
+	fields []apperrors.FieldError
+}
+
+// Check records a violation of field unless ok holds.
+func (v *Validator) Check(ok bool, field, code, message string) {
+	if !ok {
+		v.fields = append(v.fields, apperrors.FieldError{Field: field, Code: code, Message: message})
+	}
+}
+
+// Err returns a validation error with every recorded violation, or nil.
+func (v *Validator) Err() error {
+	if len(v.fields) == 0 {
+		return nil
+	}
+	return apperrors.Invalid(v.fields...)
+}
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions DROP CONSTRAINT subscriptions_end_after_start;
+
+ALTER TABLE subscriptions DROP COLUMN EndDate;
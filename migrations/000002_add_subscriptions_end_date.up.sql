@@ -0,0 +1,4 @@
+ALTER TABLE subscriptions ADD COLUMN EndDate DATE;
+
+ALTER TABLE subscriptions
+    ADD CONSTRAINT subscriptions_end_after_start CHECK (EndDate IS NULL OR EndDate >= StartDate);
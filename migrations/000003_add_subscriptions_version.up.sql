@@ -0,0 +1 @@
+ALTER TABLE subscriptions ADD COLUMN Version INTEGER NOT NULL DEFAULT 1;
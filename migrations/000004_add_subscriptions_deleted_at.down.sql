@@ -0,0 +1,3 @@
+DROP INDEX subscriptions_deleted_at_idx;
+
+ALTER TABLE subscriptions DROP COLUMN DeletedAt;
@@ -0,0 +1,3 @@
+ALTER TABLE subscriptions ADD COLUMN DeletedAt TIMESTAMPTZ;
+
+CREATE INDEX subscriptions_deleted_at_idx ON subscriptions (DeletedAt) WHERE DeletedAt IS NOT NULL;
@@ -0,0 +1,11 @@
+CREATE TABLE subscription_events (
+    ID BIGSERIAL PRIMARY KEY,
+    SubscriptionID INTEGER NOT NULL REFERENCES subscriptions (ID),
+    Action TEXT NOT NULL,
+    Actor TEXT,
+    OldData JSONB,
+    NewData JSONB,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE INDEX subscription_events_subscription_id_idx ON subscription_events (SubscriptionID, ID);
@@ -0,0 +1,9 @@
+CREATE TABLE subscription_prices (
+    SubscriptionID INTEGER NOT NULL REFERENCES subscriptions (ID),
+    EffectiveFrom DATE NOT NULL,
+    Price INTEGER NOT NULL,
+    PRIMARY KEY (SubscriptionID, EffectiveFrom)
+);
+
+INSERT INTO subscription_prices (SubscriptionID, EffectiveFrom, Price)
+SELECT ID, StartDate, Price FROM subscriptions;
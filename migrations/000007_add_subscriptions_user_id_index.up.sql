@@ -0,0 +1 @@
+CREATE INDEX subscriptions_user_id_idx ON subscriptions (UserID, ID);
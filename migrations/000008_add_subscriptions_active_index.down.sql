@@ -0,0 +1 @@
+DROP INDEX subscriptions_active_idx;
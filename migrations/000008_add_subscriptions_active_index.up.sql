@@ -0,0 +1 @@
+CREATE INDEX subscriptions_active_idx ON subscriptions (StartDate, EndDate);
@@ -0,0 +1,5 @@
+DROP TABLE exchange_rates;
+
+ALTER TABLE subscription_prices DROP COLUMN Currency;
+
+ALTER TABLE subscriptions DROP COLUMN Currency;
@@ -0,0 +1,14 @@
+ALTER TABLE subscriptions ADD COLUMN Currency CHAR(3) NOT NULL DEFAULT 'RUB';
+
+-- Every price period is charged in the currency it was set in.
+ALTER TABLE subscription_prices ADD COLUMN Currency CHAR(3) NOT NULL DEFAULT 'RUB';
+ALTER TABLE subscription_prices ALTER COLUMN Currency DROP DEFAULT;
+
+-- Rate is the price of one unit of Currency in rubles, the base currency.
+CREATE TABLE exchange_rates (
+    Currency CHAR(3) PRIMARY KEY,
+    Rate NUMERIC(20, 8) NOT NULL CHECK (Rate > 0),
+    UpdatedAt TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+INSERT INTO exchange_rates (Currency, Rate) VALUES ('RUB', 1);
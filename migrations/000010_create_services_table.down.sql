@@ -0,0 +1,7 @@
+DROP INDEX subscriptions_service_id_idx;
+
+ALTER TABLE subscriptions DROP COLUMN ServiceID;
+
+DROP TABLE service_aliases;
+
+DROP TABLE services;
@@ -0,0 +1,33 @@
+CREATE TABLE services (
+    ID SERIAL PRIMARY KEY,
+    Name TEXT NOT NULL,
+    DefaultPrice INTEGER,
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+
+CREATE UNIQUE INDEX services_name_idx ON services (lower(Name));
+
+-- Aliases are stored lower-cased, names and aliases are matched case-insensitively.
+CREATE TABLE service_aliases (
+    Alias TEXT PRIMARY KEY,
+    ServiceID INTEGER NOT NULL REFERENCES services (ID) ON DELETE CASCADE
+);
+
+CREATE INDEX service_aliases_service_id_idx ON service_aliases (ServiceID);
+
+INSERT INTO services (Name)
+SELECT DISTINCT ON (lower(btrim(ServiceName))) btrim(ServiceName)
+FROM subscriptions
+ORDER BY lower(btrim(ServiceName)), btrim(ServiceName);
+
+-- ServiceName is kept as a copy of the canonical service name.
+ALTER TABLE subscriptions ADD COLUMN ServiceID INTEGER REFERENCES services (ID);
+
+UPDATE subscriptions s
+SET ServiceID = sv.ID, ServiceName = sv.Name
+FROM services sv
+WHERE lower(sv.Name) = lower(btrim(s.ServiceName));
+
+ALTER TABLE subscriptions ALTER COLUMN ServiceID SET NOT NULL;
+
+CREATE INDEX subscriptions_service_id_idx ON subscriptions (ServiceID);
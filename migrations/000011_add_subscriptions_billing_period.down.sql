@@ -0,0 +1,5 @@
+ALTER TABLE subscription_prices DROP COLUMN BillingAnchor, DROP COLUMN BillingPeriod;
+
+ALTER TABLE subscriptions DROP COLUMN BillingAnchor;
+
+ALTER TABLE subscriptions DROP COLUMN BillingPeriod;
@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions
+    ADD COLUMN BillingPeriod TEXT NOT NULL DEFAULT 'monthly',
+    ADD CONSTRAINT subscriptions_billing_period_check
+        CHECK (BillingPeriod IN ('weekly', 'monthly', 'quarterly', 'yearly'));
+
+-- BillingAnchor is the day of the first charge, StartDate when NULL.
+ALTER TABLE subscriptions ADD COLUMN BillingAnchor DATE;
+
+-- Every price period is billed on the terms it was set with.
+ALTER TABLE subscription_prices
+    ADD COLUMN BillingPeriod TEXT NOT NULL DEFAULT 'monthly',
+    ADD CONSTRAINT subscription_prices_billing_period_check
+        CHECK (BillingPeriod IN ('weekly', 'monthly', 'quarterly', 'yearly')),
+    ADD COLUMN BillingAnchor DATE;
+ALTER TABLE subscription_prices ALTER COLUMN BillingPeriod DROP DEFAULT;
@@ -0,0 +1,26 @@
+CREATE EXTENSION IF NOT EXISTS btree_gist;
+
+-- A trial makes the months free, a percent discount takes Value percent off
+-- and a fixed one takes Value off the price of each billing event, in the
+-- currency of the charge. Discounts of a subscription must not overlap, so at
+-- most one applies to a month.
+CREATE TABLE subscription_discounts (
+    ID SERIAL PRIMARY KEY,
+    SubscriptionID INTEGER NOT NULL REFERENCES subscriptions (ID),
+    Type TEXT NOT NULL CHECK (Type IN ('trial', 'percent', 'fixed')),
+    Value INTEGER NOT NULL DEFAULT 0,
+    StartMonth DATE NOT NULL,
+    Months INTEGER NOT NULL CHECK (Months > 0),
+    CreatedAt TIMESTAMPTZ NOT NULL DEFAULT now(),
+    CHECK (
+        (Type = 'trial' AND Value = 0)
+        OR (Type = 'percent' AND Value BETWEEN 1 AND 100)
+        OR (Type = 'fixed' AND Value > 0)
+    ),
+    CONSTRAINT subscription_discounts_no_overlap EXCLUDE USING gist (
+        SubscriptionID WITH =,
+        daterange(StartMonth, (StartMonth + Months * interval '1 month')::date) WITH &&
+    )
+);
+
+CREATE INDEX subscription_discounts_subscription_id_idx ON subscription_discounts (SubscriptionID, StartMonth);
@@ -0,0 +1,3 @@
+DROP TABLE subscription_pauses;
+
+ALTER TABLE subscriptions DROP COLUMN Status;
@@ -0,0 +1,15 @@
+ALTER TABLE subscriptions
+    ADD COLUMN Status TEXT NOT NULL DEFAULT 'active',
+    ADD CONSTRAINT subscriptions_status_check CHECK (Status IN ('active', 'paused', 'cancelled'));
+
+-- A subscription is not charged for the months [StartMonth, EndMonth),
+-- EndMonth is NULL until it is resumed.
+CREATE TABLE subscription_pauses (
+    ID SERIAL PRIMARY KEY,
+    SubscriptionID INTEGER NOT NULL REFERENCES subscriptions (ID),
+    StartMonth DATE NOT NULL,
+    EndMonth DATE,
+    CHECK (EndMonth >= StartMonth)
+);
+
+CREATE INDEX subscription_pauses_subscription_id_idx ON subscription_pauses (SubscriptionID, StartMonth);